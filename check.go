@@ -0,0 +1,247 @@
+package zconfig
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Validators for the tags understood by zconfig, used by Check to detect
+// invalid values and unknown tags. A nil validator accepts any value.
+var tagValidators = map[string]func(f *Field, value string) error{
+	TagKey:         validateKey,
+	TagDefault:     validateDefault,
+	TagDescription: nil,
+	TagInject:      nil,
+	TagInjectAs:    nil,
+	TagSecret:      validateBool,
+	TagSources:     validateSources,
+	TagEnum:        validateEnum,
+	TagInitTimeout: validateTimeout,
+	TagInitRetry:   validateRetry,
+	TagAfter:       nil,
+	TagEnabled:     nil,
+	TagReload:      validateBool,
+	TagDeprecated:  nil,
+}
+
+// A CheckError lists every problem found by Check in a struct.
+type CheckError struct {
+	Errors []error
+}
+
+func (e *CheckError) Error() string {
+	var msgs = make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d problem(s) found: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Check walks the given struct the same way Process does, without running
+// any hook, and reports the problems it can find in its definition: unknown
+// or invalid tags, invalid or duplicate configuration keys and, if the
+// processor has a repository, default values that can't be parsed. It is
+// intended to be used in unit tests, as a lint for configuration structs.
+func (p *Processor) Check(s interface{}) error {
+	root, fields, err := prepare(s)
+	if err != nil {
+		return &CheckError{Errors: []error{err}}
+	}
+
+	var errs []error
+	for _, f := range flatten(root) {
+		for _, name := range tagNames(f.Tags) {
+			validator, ok := tagValidators[name]
+			if !ok {
+				if !p.ignoredTag(name) {
+					errs = append(errs, fmt.Errorf("field %s: unknown tag %s", f.Path, name))
+				}
+				continue
+			}
+
+			if validator == nil {
+				continue
+			}
+
+			err := validator(f, f.Tags.Get(name))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("field %s: invalid tag %s: %w", f.Path, name, err))
+			}
+		}
+
+		if p.Repository != nil {
+			err := p.Repository.Check(f)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("field %s: %w", f.Path, err))
+			}
+		}
+	}
+
+	errs = append(errs, checkKeys(fields)...)
+
+	if len(errs) != 0 {
+		return &CheckError{Errors: errs}
+	}
+
+	return nil
+}
+
+func (p *Processor) ignoredTag(name string) bool {
+	for _, tag := range p.IgnoredTags {
+		if tag == name {
+			return true
+		}
+	}
+	return false
+}
+
+var keyPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+func validateKey(_ *Field, key string) error {
+	if key == "" {
+		return fmt.Errorf("empty key")
+	}
+
+	for _, part := range strings.Split(key, ".") {
+		if !keyPattern.MatchString(part) {
+			return fmt.Errorf("invalid key %q", key)
+		}
+	}
+
+	return nil
+}
+
+func validateDefault(f *Field, _ string) error {
+	if !f.Configurable {
+		return fmt.Errorf("default value for non configurable field")
+	}
+	return nil
+}
+
+func validateBool(_ *Field, value string) error {
+	_, err := strconv.ParseBool(value)
+	return err
+}
+
+func validateSources(f *Field, _ string) error {
+	if !f.Configurable {
+		return fmt.Errorf("sources for non configurable field")
+	}
+
+	for _, source := range f.Sources() {
+		if source == "" {
+			return fmt.Errorf("empty source name")
+		}
+	}
+	return nil
+}
+
+func validateEnum(f *Field, _ string) error {
+	if !f.Configurable {
+		return fmt.Errorf("enum for non configurable field")
+	}
+
+	for _, value := range f.Enum() {
+		if value == "" {
+			return fmt.Errorf("empty enum value")
+		}
+	}
+	return nil
+}
+
+func validateTimeout(_ *Field, value string) error {
+	_, err := parseTimeout(value)
+	return err
+}
+
+func validateRetry(_ *Field, value string) error {
+	_, err := parseRetry(value)
+	return err
+}
+
+// Return an error for every configurable field whose configuration key, or
+// environment variable name, is already used by another field.
+func checkKeys(fields []*Field) (errs []error) {
+	var (
+		keys = make(map[string]*Field)
+		envs = make(map[string]*Field)
+	)
+	for _, f := range fields {
+		if !f.Configurable {
+			continue
+		}
+
+		if other, ok := keys[f.ConfigurationKey]; ok {
+			errs = append(errs, fmt.Errorf("duplicate key %s for fields %s and %s", f.ConfigurationKey, other.Path, f.Path))
+			continue
+		}
+		keys[f.ConfigurationKey] = f
+
+		env := Env.FormatKey(f.ConfigurationKey)
+		if other, ok := envs[env]; ok {
+			errs = append(errs, fmt.Errorf("keys %s and %s of fields %s and %s share the same environment variable %s", other.ConfigurationKey, f.ConfigurationKey, other.Path, f.Path, env))
+			continue
+		}
+		envs[env] = f
+	}
+	return errs
+}
+
+// Return the fields of the tree in declaration order.
+func flatten(root *Field) (fields []*Field) {
+	fields = append(fields, root)
+	for _, c := range root.Children {
+		fields = append(fields, flatten(c)...)
+	}
+	return fields
+}
+
+// Return the names of the tags in the given struct tag, following the
+// convention used by reflect.StructTag.Lookup.
+func tagNames(tag reflect.StructTag) (names []string) {
+	for tag != "" {
+		// Skip leading space.
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		// Scan to colon. A space, a quote or a control character is a
+		// syntax error.
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+		name := string(tag[:i])
+		tag = tag[i+1:]
+
+		// Scan quoted string to find value.
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		if _, err := strconv.Unquote(string(tag[:i+1])); err != nil {
+			break
+		}
+		tag = tag[i+1:]
+
+		names = append(names, name)
+	}
+	return names
+}
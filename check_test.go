@@ -0,0 +1,138 @@
+package zconfig
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DuplicateF struct {
+	B int `key:"b"`
+}
+
+func TestCheck(t *testing.T) {
+	var repository Repository
+	repository.AddParsers(ParseString)
+
+	var p Processor
+	p.Repository = &repository
+
+	t.Run("valid", func(t *testing.T) {
+		err := p.Check(new(S))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	for name, tCase := range map[string]struct {
+		s        interface{}
+		expected string
+	}{
+		"invalid default": {
+			s: new(struct {
+				Timeout time.Duration `key:"timeout" default:"3O s"`
+			}),
+			expected: "field $.Timeout: parsing default value for key timeout",
+		},
+		"invalid key": {
+			s: new(struct {
+				Foo int `key:"foo bar"`
+			}),
+			expected: `field $.Foo: invalid tag key: invalid key "foo bar"`,
+		},
+		"empty key": {
+			s: new(struct {
+				Foo int `key:""`
+			}),
+			expected: "invalid empty key for field $.Foo",
+		},
+		"unknown tag": {
+			s: new(struct {
+				Foo int `key:"foo" defualt:"1"`
+			}),
+			expected: "field $.Foo: unknown tag defualt",
+		},
+		"default without key": {
+			s: new(struct {
+				Foo int `default:"1"`
+			}),
+			expected: "field $.Foo: invalid tag default: default value for non configurable field",
+		},
+		"duplicate key": {
+			s: new(struct {
+				F
+				DuplicateF
+			}),
+			expected: "duplicate key b for fields",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := p.Check(tCase.s)
+			if err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+
+			var checkErr *CheckError
+			if !errors.As(err, &checkErr) {
+				t.Fatalf("unexpected error type %T", err)
+			}
+
+			if !strings.Contains(err.Error(), tCase.expected) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	t.Run("ignored tags", func(t *testing.T) {
+		var p = Processor{IgnoredTags: []string{"json"}}
+		err := p.Check(new(struct {
+			Foo int `key:"foo" json:"foo"`
+		}))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("secret default", func(t *testing.T) {
+		err := p.Check(new(struct {
+			Password int `key:"password" default:"hunter2" secret:"true"`
+		}))
+		if err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+		if strings.Contains(err.Error(), "hunter2") {
+			t.Fatalf("secret leaked in error: %v", err)
+		}
+	})
+
+	t.Run("no side effect", func(t *testing.T) {
+		var s struct {
+			Foo int `key:"foo" default:"1"`
+		}
+		err := p.Check(&s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if s.Foo != 0 {
+			t.Fatalf("unexpected value set: %d", s.Foo)
+		}
+	})
+}
+
+func TestTagNames(t *testing.T) {
+	for tag, expected := range map[reflect.StructTag][]string{
+		``:                                  nil,
+		`key:"foo"`:                         {"key"},
+		`key:"foo" default:"a \"b\" c"`:     {"key", "default"},
+		`  key:"foo"   description:"x y" `:  {"key", "description"},
+		`key:"foo" invalid`:                 {"key"},
+		`json:"foo,omitempty" inject-as:""`: {"json", "inject-as"},
+	} {
+		actual := tagNames(tag)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("tagNames(%s): wanted %v, got %v", tag, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package zconfig
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Shells supported by GenerateCompletion.
+const (
+	ShellBash = "bash"
+	ShellZsh  = "zsh"
+	ShellFish = "fish"
+)
+
+// GenerateCompletion generates a script completing the command-line flags of
+// the configurable fields among the given fields for the bash, zsh or fish
+// shell, named after the program. The values of the enum and boolean fields
+// are completed too, and a hint is shown for the durations.
+func GenerateCompletion(fields []*Field, shell string) ([]byte, error) {
+	var flags []completionFlag
+	for _, f := range fields {
+		if f.Configurable {
+			flags = append(flags, newCompletionFlag(f))
+		}
+	}
+	sort.Slice(flags, func(a, b int) bool {
+		return flags[a].key < flags[b].key
+	})
+
+	program := filepath.Base(os.Args[0])
+
+	var buf bytes.Buffer
+	switch shell {
+	case ShellBash:
+		writeBashCompletion(&buf, program, flags)
+	case ShellZsh:
+		writeZshCompletion(&buf, program, flags)
+	case ShellFish:
+		writeFishCompletion(&buf, program, flags)
+	default:
+		return nil, fmt.Errorf("unknown shell %q", shell)
+	}
+
+	return buf.Bytes(), nil
+}
+
+const durationHint = "duration, e.g. 1m30s"
+
+// The completion of the flag of a configurable field: its values if they
+// are known, or a hint about them.
+type completionFlag struct {
+	key         string
+	description string
+	values      []string
+	hint        string
+}
+
+func newCompletionFlag(f *Field) completionFlag {
+	flag := completionFlag{
+		key:         f.ConfigurationKey,
+		description: describe(f),
+		values:      f.Enum(),
+	}
+
+	t := reflect.TypeOf(valueOf(f.newValue()))
+	switch {
+	case t == nil || flag.values != nil:
+	case t == typeDuration:
+		flag.hint = durationHint
+	case t.Kind() == reflect.Bool:
+		flag.values = []string{"true", "false"}
+	}
+	return flag
+}
+
+var notIdentifier = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+func writeBashCompletion(buf *bytes.Buffer, program string, flags []completionFlag) {
+	function := "_" + notIdentifier.ReplaceAllString(program, "_") + "_completion"
+
+	var names []string
+	for _, f := range flags {
+		names = append(names, "--"+f.key)
+	}
+
+	fmt.Fprintf(buf, "# bash completion for %s\n", program)
+	fmt.Fprintf(buf, "%s() {\n", function)
+	buf.WriteString("\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	buf.WriteString("\n")
+	buf.WriteString("\t# The = of --key=value is a word of its own.\n")
+	buf.WriteString("\tif [[ \"$cur\" == \"=\" ]]; then\n")
+	buf.WriteString("\t\tcur=\"\"\n")
+	buf.WriteString("\telif [[ \"$prev\" == \"=\" ]]; then\n")
+	buf.WriteString("\t\tprev=\"${COMP_WORDS[COMP_CWORD-2]}\"\n")
+	buf.WriteString("\tfi\n")
+	buf.WriteString("\n")
+	buf.WriteString("\tif [[ \"$cur\" != -* ]]; then\n")
+	buf.WriteString("\t\tcase \"$prev\" in\n")
+	for _, f := range flags {
+		switch {
+		case f.values != nil:
+			fmt.Fprintf(buf, "\t\t--%s)\n\t\t\tCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n\t\t\treturn\n\t\t\t;;\n", f.key, quoteShell(strings.Join(f.values, " ")))
+		case f.hint != "":
+			// Two candidates to display the hint without inserting it.
+			fmt.Fprintf(buf, "\t\t--%s)\n\t\t\tCOMPREPLY=(%s \"\")\n\t\t\treturn\n\t\t\t;;\n", f.key, quoteShell("<"+f.hint+">"))
+		}
+	}
+	buf.WriteString("\t\tesac\n")
+	buf.WriteString("\tfi\n")
+	buf.WriteString("\n")
+	fmt.Fprintf(buf, "\tCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n", quoteShell(strings.Join(names, " ")))
+	buf.WriteString("}\n")
+	fmt.Fprintf(buf, "complete -F %s %s\n", function, quoteShell(program))
+}
+
+func writeZshCompletion(buf *bytes.Buffer, program string, flags []completionFlag) {
+	// Escape the characters special to the specifications of _arguments,
+	// which are single-quoted.
+	escape := strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`, "'", `'\''`).Replace
+
+	fmt.Fprintf(buf, "#compdef %s\n", program)
+	buf.WriteString("\n_arguments")
+	for _, f := range flags {
+		message, action := f.key, "_default"
+		switch {
+		case f.values != nil:
+			action = "(" + strings.Join(f.values, " ") + ")"
+		case f.hint != "":
+			message, action = f.hint, " " // Display the message only.
+		}
+
+		fmt.Fprintf(buf, " \\\n\t'--%s=[%s]:%s:%s'", f.key, escape(f.description), escape(message), escape(action))
+	}
+	buf.WriteString("\n")
+}
+
+func writeFishCompletion(buf *bytes.Buffer, program string, flags []completionFlag) {
+	quote := func(s string) string {
+		return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+	}
+
+	fmt.Fprintf(buf, "# fish completion for %s\n", program)
+	for _, f := range flags {
+		fmt.Fprintf(buf, "complete -c %s -l %s", quote(program), quote(f.key))
+		switch {
+		case f.values != nil:
+			fmt.Fprintf(buf, " -x -a %s", quote(strings.Join(f.values, " ")))
+		case f.hint != "":
+			fmt.Fprintf(buf, " -x")
+		default:
+			fmt.Fprintf(buf, " -r")
+		}
+
+		description := f.description
+		switch {
+		case f.hint == "":
+		case description == "":
+			description = f.hint
+		default:
+			description += " (" + f.hint + ")"
+		}
+		if description != "" {
+			fmt.Fprintf(buf, " -d %s", quote(description))
+		}
+		buf.WriteString("\n")
+	}
+}
@@ -0,0 +1,78 @@
+package zconfig
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateCompletion(t *testing.T) {
+	var s struct {
+		Level   string        `key:"level" default:"info" enum:"debug,info" description:"Level [of] the 'logs'"`
+		Debug   bool          `key:"debug" default:"false"`
+		Timeout time.Duration `key:"timeout" default:"1s"`
+		DB      struct {
+			Host string `key:"host"`
+		} `key:"db"`
+	}
+
+	_, fields, err := prepare(&s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for shell, expected := range map[string][]string{
+		ShellBash: {
+			"COMPREPLY=($(compgen -W '--db.host --debug --level --timeout' -- \"$cur\"))\n",
+			"\t\t--level)\n\t\t\tCOMPREPLY=($(compgen -W 'debug info' -- \"$cur\"))\n",
+			"\t\t--debug)\n\t\t\tCOMPREPLY=($(compgen -W 'true false' -- \"$cur\"))\n",
+			"\t\t--timeout)\n\t\t\tCOMPREPLY=('<duration, e.g. 1m30s>' \"\")\n",
+		},
+		ShellZsh: {
+			"#compdef ",
+			`'--level=[Level \[of\] the '\''logs'\'' (one of\: debug, info)]:level:(debug info)'`,
+			`'--debug=[]:debug:(true false)'`,
+			`'--timeout=[]:duration, e.g. 1m30s: '`,
+			`'--db.host=[]:db.host:_default'`,
+		},
+		ShellFish: {
+			`-l 'level' -x -a 'debug info' -d 'Level [of] the \'logs\' (one of: debug, info)'`,
+			`-l 'debug' -x -a 'true false'`,
+			`-l 'timeout' -x -d 'duration, e.g. 1m30s'`,
+			`-l 'db.host' -r`,
+		},
+	} {
+		raw, err := GenerateCompletion(fields, shell)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", shell, err)
+		}
+
+		for _, part := range expected {
+			if !strings.Contains(string(raw), part) {
+				t.Errorf("missing %q from %s completion:\n%s", part, shell, raw)
+			}
+		}
+
+		// Check the syntax of the script if the shell is available.
+		if path, err := exec.LookPath(shell); err == nil {
+			script := filepath.Join(t.TempDir(), "completion")
+			err := os.WriteFile(script, raw, 0o600)
+			if err != nil {
+				t.Fatalf("writing script: %s", err)
+			}
+
+			out, err := exec.Command(path, "-n", script).CombinedOutput()
+			if err != nil {
+				t.Errorf("invalid %s completion: %s\n%s", shell, out, raw)
+			}
+		}
+	}
+
+	_, err = GenerateCompletion(fields, "csh")
+	if err == nil {
+		t.Fatal("expected an error for an unknown shell, got nil")
+	}
+}
@@ -0,0 +1,218 @@
+package zconfig
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Formats supported by GenerateDocs.
+const (
+	FormatMarkdown = "markdown"
+	FormatMan      = "man"
+	FormatAsciiDoc = "asciidoc"
+)
+
+// GenerateDocs generates the documentation of the configurable fields among
+// the given fields in one of the markdown, man or asciidoc formats. The keys
+// are grouped by parent struct, in declaration order, and listed with their
+// command-line flag, environment variable, type, default value, description,
+// allowed values and condition. The man page is named after the program.
+// The output is deterministic, so it can be committed and checked for
+// changes.
+func GenerateDocs(fields []*Field, format string) ([]byte, error) {
+	groups := docGroups(newKeyTree(fields), "")
+
+	var buf bytes.Buffer
+	switch format {
+	case FormatMarkdown:
+		writeMarkdownDocs(&buf, groups)
+	case FormatMan:
+		writeManDocs(&buf, groups)
+	case FormatAsciiDoc:
+		writeAsciiDocDocs(&buf, groups)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// A group of keys documented together, sharing the same parent struct.
+type docGroup struct {
+	prefix      string
+	description string
+	fields      []*Field
+}
+
+// Return the groups of keys of the tree, a group preceding the ones of its
+// sub-sections.
+func docGroups(node *keyNode, prefix string) (groups []docGroup) {
+	group := docGroup{prefix: prefix, description: node.description}
+	for _, c := range node.children {
+		if c.field != nil {
+			group.fields = append(group.fields, c.field)
+		}
+	}
+	if len(group.fields) != 0 {
+		groups = append(groups, group)
+	}
+
+	for _, c := range node.children {
+		if c.field == nil {
+			name := c.name
+			if prefix != "" {
+				name = prefix + "." + c.name
+			}
+			groups = append(groups, docGroups(c, name)...)
+		}
+	}
+	return groups
+}
+
+// The documented attributes of a configurable field.
+type docEntry struct {
+	flag, env, typ, def, description string
+	required                         bool
+}
+
+func newDocEntry(f *Field) docEntry {
+	e := docEntry{
+		flag:        "--" + f.ConfigurationKey,
+		env:         Env.FormatKey(f.ConfigurationKey),
+		typ:         typeName(f),
+		description: describe(f),
+	}
+
+	def, ok := f.Tags.Lookup(TagDefault)
+	e.def, e.required = def, !ok
+	if f.IsSecret() {
+		if ok {
+			e.def = Redacted
+		}
+		e.description = strings.TrimSpace(e.description + " (secret)")
+	}
+	return e
+}
+
+// Return the name of the type of the value of a field, unwrapping the
+// pointers and the Dynamic values.
+func typeName(f *Field) string {
+	if t := reflect.TypeOf(valueOf(f.newValue())); t != nil {
+		return t.String()
+	}
+
+	t := f.Value.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.String()
+}
+
+// Return the title of a group, the top-level keys having none.
+func (g docGroup) title() string {
+	if g.prefix == "" {
+		return "General"
+	}
+	return g.prefix
+}
+
+func writeMarkdownDocs(buf *bytes.Buffer, groups []docGroup) {
+	cell := strings.NewReplacer("|", `\|`, "\n", " ").Replace
+	code := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "`" + cell(s) + "`"
+	}
+
+	buf.WriteString("# Configuration\n")
+	for _, g := range groups {
+		fmt.Fprintf(buf, "\n## %s\n\n", g.title())
+		if g.description != "" {
+			fmt.Fprintf(buf, "%s\n\n", g.description)
+		}
+
+		buf.WriteString("| Flag | Variable | Type | Default | Description |\n")
+		buf.WriteString("|------|----------|------|---------|-------------|\n")
+		for _, f := range g.fields {
+			e := newDocEntry(f)
+			def := code(e.def)
+			if e.required {
+				def = "*required*"
+			}
+			fmt.Fprintf(buf, "| %s | %s | %s | %s | %s |\n", code(e.flag), code(e.env), code(e.typ), def, cell(e.description))
+		}
+	}
+}
+
+func writeManDocs(buf *bytes.Buffer, groups []docGroup) {
+	roff := strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace
+	line := func(s string) string {
+		s = roff(s)
+		if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+			s = `\&` + s
+		}
+		return s
+	}
+
+	name := strings.ToUpper(filepath.Base(os.Args[0]))
+	fmt.Fprintf(buf, ".TH %s 1\n", roff(name))
+	buf.WriteString(".SH CONFIGURATION\n")
+	buf.WriteString("Each key can be given as a command\\-line flag or as an environment variable.\n")
+	for _, g := range groups {
+		fmt.Fprintf(buf, ".SS %s\n", line(g.title()))
+		if g.description != "" {
+			fmt.Fprintf(buf, "%s\n", line(g.description))
+		}
+
+		for _, f := range g.fields {
+			e := newDocEntry(f)
+			buf.WriteString(".TP\n")
+			fmt.Fprintf(buf, "\\fB%s\\fR, \\fB%s\\fR (\\fI%s\\fR)\n", roff(e.flag), roff(e.env), roff(e.typ))
+			if e.description != "" {
+				fmt.Fprintf(buf, "%s\n", line(e.description))
+				buf.WriteString(".br\n")
+			}
+			if e.required {
+				buf.WriteString("Required.\n")
+			} else {
+				fmt.Fprintf(buf, "Default: %s\n", roff(e.def))
+			}
+		}
+	}
+}
+
+func writeAsciiDocDocs(buf *bytes.Buffer, groups []docGroup) {
+	cell := strings.NewReplacer("|", `\|`, "\n", " ").Replace
+	code := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "`+" + cell(s) + "+`"
+	}
+
+	buf.WriteString("= Configuration\n")
+	for _, g := range groups {
+		fmt.Fprintf(buf, "\n== %s\n\n", g.title())
+		if g.description != "" {
+			fmt.Fprintf(buf, "%s\n\n", g.description)
+		}
+
+		buf.WriteString("[cols=\"2,2,1,1,4\",options=\"header\"]\n")
+		buf.WriteString("|===\n")
+		buf.WriteString("|Flag |Variable |Type |Default |Description\n")
+		for _, f := range g.fields {
+			e := newDocEntry(f)
+			def := code(e.def)
+			if e.required {
+				def = "_required_"
+			}
+			fmt.Fprintf(buf, "\n|%s\n|%s\n|%s\n|%s\n|%s\n", code(e.flag), code(e.env), code(e.typ), def, cell(e.description))
+		}
+		buf.WriteString("|===\n")
+	}
+}
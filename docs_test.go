@@ -0,0 +1,78 @@
+package zconfig
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateDocs(t *testing.T) {
+	_, fields, err := prepare(new(sampleTest))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for format, expected := range map[string][]string{
+		FormatMarkdown: {
+			"# Configuration\n\n## General\n\n| Flag | Variable | Type | Default | Description |\n",
+			"| `--name` | `NAME` | `string` | `my service` | Name of the service |\n",
+			"| `--level` | `LEVEL` | `string` | `info` | (one of: debug, info) |\n",
+			"## db\n\nDatabase connection\n\n",
+			"| `--db.host` | `DB_HOST` | `string` | *required* | Host of the database |\n",
+			"| `--db.password` | `DB_PASSWORD` | `string` | `***` | (secret) |\n",
+			"## db.pool\n\n",
+		},
+		FormatMan: {
+			".SH CONFIGURATION\n",
+			".SS db\nDatabase connection\n.TP\n\\fB\\-\\-db.host\\fR, \\fBDB_HOST\\fR (\\fIstring\\fR)\nHost of the database\n.br\nRequired.\n",
+			"Default: 5432\n",
+		},
+		FormatAsciiDoc: {
+			"= Configuration\n\n== General\n",
+			"|`+--db.host+`\n|`+DB_HOST+`\n|`+string+`\n|_required_\n|Host of the database\n",
+		},
+	} {
+		raw, err := GenerateDocs(fields, format)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", format, err)
+		}
+
+		for _, part := range expected {
+			if !strings.Contains(string(raw), part) {
+				t.Errorf("missing %q from %s docs:\n%s", part, format, raw)
+			}
+		}
+		if strings.Contains(string(raw), "admin") {
+			t.Errorf("secret displayed in %s docs:\n%s", format, raw)
+		}
+	}
+
+	_, err = GenerateDocs(fields, "xml")
+	if err == nil {
+		t.Fatal("expected an error for an unknown format, got nil")
+	}
+}
+
+func TestTypeName(t *testing.T) {
+	var s struct {
+		A *time.Duration       `key:"a"`
+		B Dynamic[[]string]    `key:"b"`
+		C Dynamic[interface{}] `key:"c"`
+		D map[string]int       `key:"d"`
+	}
+
+	_, fields, err := prepare(&s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{"a": "time.Duration", "b": "[]string", "c": "zconfig.Dynamic[interface {}]", "d": "map[string]int"}
+	for _, f := range fields {
+		if !f.Configurable {
+			continue
+		}
+		if actual := typeName(f); actual != expected[f.ConfigurationKey] {
+			t.Errorf("unexpected type for %s: %s", f.ConfigurationKey, actual)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package zconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats supported by Dump.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatEnv  = "env"
+	FormatCLI  = "cli"
+)
+
+// Dump the values of the configurable fields among the given processed fields
+// in one of the json, yaml, env or cli formats, the secrets being redacted.
+// The JSON and YAML documents nest the values following the dotted keys. The
+// output can be read back by the FileProvider for the json, yaml and env
+// formats, and by the ArgsProvider for the cli format. The fields that
+// weren't configured, because they belong to a disabled branch, are omitted.
+func Dump(fields []*Field, format string) ([]byte, error) {
+	values := make(map[string]interface{})
+	for _, f := range fields {
+		if !f.Configurable || f.Provider == "" {
+			continue
+		}
+
+		if f.IsSecret() {
+			values[f.ConfigurationKey] = Redacted
+			continue
+		}
+		values[f.ConfigurationKey] = dumpValue(reflect.ValueOf(valueOf(pointer(f.Value).Interface())))
+	}
+
+	switch format {
+	case FormatJSON:
+		doc, err := nest(values)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(raw, '\n'), nil
+	case FormatYAML:
+		doc, err := nest(values)
+		if err != nil {
+			return nil, err
+		}
+		return yaml.Marshal(doc)
+	case FormatEnv:
+		var buf bytes.Buffer
+		for _, key := range sortedKeys(values) {
+			fmt.Fprintf(&buf, "%s=%s\n", Env.FormatKey(key), quoteEnv(flatValue(values[key])))
+		}
+		return buf.Bytes(), nil
+	case FormatCLI:
+		var args []string
+		for _, key := range sortedKeys(values) {
+			args = append(args, quoteShell("--"+key+"="+flatValue(values[key])))
+		}
+		return []byte(strings.Join(args, " ") + "\n"), nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// Return the value in a form suitable to be serialized and read back by the
+// ParseString parser: the values with a textual representation are kept as
+// strings, the booleans and numbers as is, and the slices as lists.
+func dumpValue(v reflect.Value) interface{} {
+	switch value := v.Interface().(type) {
+	case encoding.TextMarshaler:
+		raw, err := value.MarshalText()
+		if err == nil {
+			return string(raw)
+		}
+	case []byte:
+		return string(value)
+	case regexp.Regexp:
+		return value.String()
+	case fmt.Stringer:
+		return value.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return v.Interface()
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, dumpValue(v.Index(i)))
+		}
+		return items
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return dumpValue(v.Elem())
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// Return the value as a single string, the lists being joined by commas.
+func flatValue(value interface{}) string {
+	if items, ok := value.([]interface{}); ok {
+		var values []string
+		for _, item := range items {
+			values = append(values, fmt.Sprint(item))
+		}
+		return strings.Join(values, ",")
+	}
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// Build a tree of maps from the values indexed by dotted keys.
+func nest(values map[string]interface{}) (map[string]interface{}, error) {
+	doc := make(map[string]interface{})
+	for _, key := range sortedKeys(values) {
+		parts := strings.Split(key, ".")
+
+		node := doc
+		for i, part := range parts[:len(parts)-1] {
+			child, ok := node[part]
+			if !ok {
+				child = make(map[string]interface{})
+				node[part] = child
+			}
+
+			m, ok := child.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("key %s conflicts with key %s", key, strings.Join(parts[:i+1], "."))
+			}
+			node = m
+		}
+
+		last := parts[len(parts)-1]
+		if _, ok := node[last]; ok {
+			return nil, fmt.Errorf("key %s conflicts with the keys it prefixes", key)
+		}
+		node[last] = values[key]
+	}
+	return doc, nil
+}
+
+// Quote a value of an env file if needed, as decodeEnvFile expects it.
+func quoteEnv(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\"'#\\\n") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// Quote a command-line argument for a POSIX shell if needed.
+func quoteShell(arg string) string {
+	if !strings.ContainsAny(arg, " \t\n\"'\\$`!*?[](){}<>|&;#~") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// Configure the fields using only the hook of the repository, then dump their
+// values to the given writer. The other hooks are never executed, so nothing
+// gets initialized.
+func (p *Processor) printConfig(ctx context.Context, w io.Writer, fields []*Field, format string) error {
+	if p.Repository == nil {
+		return errors.New("no repository set on the processor")
+	}
+
+	err := p.execute(ctx, p.Repository.Hook, fields)
+	if err != nil {
+		return err
+	}
+
+	raw, err := Dump(fields, format)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(raw)
+	return err
+}
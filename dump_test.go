@@ -0,0 +1,159 @@
+package zconfig
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type dumpTest struct {
+	Name    string        `key:"name"`
+	Port    int           `key:"port"`
+	Debug   bool          `key:"debug"`
+	Timeout time.Duration `key:"timeout"`
+	Tags    []string      `key:"tags"`
+	Rate    float64       `key:"rate"`
+	DB      struct {
+		Host     string `key:"host"`
+		Password string `key:"password" secret:"true"`
+	} `key:"db"`
+}
+
+func TestDump(t *testing.T) {
+	var provider = TestProvider{name: "test", values: map[string]string{
+		"name":        "my service",
+		"port":        "8080",
+		"debug":       "true",
+		"timeout":     "1m30s",
+		"tags":        "a,b",
+		"rate":        "0.5",
+		"db.host":     "localhost",
+		"db.password": "s3cr3t",
+	}}
+
+	configure := func(t *testing.T, provider Provider) (*dumpTest, []*Field) {
+		var repository Repository
+		repository.AddProviders(provider)
+		repository.AddParsers(ParseString)
+
+		var s dumpTest
+		fields, err := NewProcessor(repository.Hook).process(context.Background(), &s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return &s, fields
+	}
+
+	s, fields := configure(t, provider)
+
+	t.Run("formats", func(t *testing.T) {
+		for format, expected := range map[string]string{
+			FormatJSON: `"db": {
+    "host": "localhost",
+    "password": "***"
+  },`,
+			FormatYAML: "db:\n    host: localhost\n    password: '***'\n",
+			FormatEnv:  "DB_PASSWORD=***\nDEBUG=true\nNAME=\"my service\"\nPORT=8080\nRATE=0.5\nTAGS=a,b\nTIMEOUT=1m30s\n",
+			FormatCLI:  "--db.host=localhost '--db.password=***' --debug=true '--name=my service' --port=8080 --rate=0.5 --tags=a,b --timeout=1m30s\n",
+		} {
+			raw, err := Dump(fields, format)
+			if err != nil {
+				t.Fatalf("unexpected error for %s: %v", format, err)
+			}
+			if !strings.Contains(string(raw), expected) {
+				t.Errorf("unexpected %s output:\n%s", format, raw)
+			}
+			if strings.Contains(string(raw), "s3cr3t") {
+				t.Errorf("secret displayed in %s output:\n%s", format, raw)
+			}
+		}
+
+		_, err := Dump(fields, "xml")
+		if err == nil {
+			t.Fatal("expected an error for an unknown format, got nil")
+		}
+	})
+
+	t.Run("round-trip", func(t *testing.T) {
+		for format, file := range map[string]string{
+			FormatJSON: "config.json",
+			FormatYAML: "config.yaml",
+			FormatEnv:  "config.env",
+		} {
+			raw, err := Dump(fields, format)
+			if err != nil {
+				t.Fatalf("unexpected error for %s: %v", format, err)
+			}
+
+			path := filepath.Join(t.TempDir(), file)
+			err = os.WriteFile(path, raw, 0o600)
+			if err != nil {
+				t.Fatalf("writing file: %s", err)
+			}
+
+			provider, err := NewFileProvider(path)
+			if err != nil {
+				t.Fatalf("reading %s dump: %v", format, err)
+			}
+
+			actual, _ := configure(t, provider)
+			expected := *s
+			expected.DB.Password = Redacted
+			if !reflect.DeepEqual(*actual, expected) {
+				t.Errorf("unexpected %s round-trip: wanted %+v, got %+v", format, expected, *actual)
+			}
+		}
+	})
+
+	t.Run("conflicting keys", func(t *testing.T) {
+		_, err := nest(map[string]interface{}{"a": 1, "a.b": 2})
+		if err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+	})
+}
+
+func TestPrintConfig(t *testing.T) {
+	var repository Repository
+	repository.AddProviders(TestProvider{name: "env", values: map[string]string{"a": "value"}})
+	repository.AddParsers(ParseString)
+
+	var initialized bool
+	initialize := func(ctx context.Context, f *Field) error {
+		initialized = true
+		return nil
+	}
+
+	_, fields, err := prepare(new(struct {
+		A string `key:"a"`
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Without repository, the hooks can't be told apart.
+	var buf bytes.Buffer
+	err = NewProcessor(repository.Hook, initialize).printConfig(context.Background(), &buf, fields, FormatEnv)
+	if err == nil {
+		t.Fatal("expected an error without repository, got nil")
+	}
+
+	p := NewProcessor(repository.Hook, initialize)
+	p.Repository = &repository
+	err = p.printConfig(context.Background(), &buf, fields, FormatEnv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != "A=value\n" {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+
+	if initialized {
+		t.Fatal("hooks other than the repository one executed")
+	}
+}
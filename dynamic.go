@@ -0,0 +1,80 @@
+package zconfig
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Dynamic holds a value that can change while the service is running. It is
+// configured like a field of type T, with the same parsers, tags and help,
+// and is implicitly reloadable: Watch stores the new values in it. Load is
+// safe for concurrent use and never blocks.
+type Dynamic[T any] struct {
+	value atomic.Pointer[T]
+
+	lock        sync.Mutex
+	subscribers []func(old, new T)
+}
+
+// The dynamic interface is used by the repository to configure the value
+// wrapped by a Dynamic field.
+type dynamic interface {
+	newValue() interface{}
+	load() interface{}
+	store(interface{})
+}
+
+// Load returns the current value, or the zero value of T if it was never
+// set.
+func (d *Dynamic[T]) Load() (value T) {
+	if v := d.value.Load(); v != nil {
+		return *v
+	}
+	return value
+}
+
+// Store sets the value, then calls the subscribed functions with the
+// previous and the new value.
+func (d *Dynamic[T]) Store(value T) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	var old T
+	if v := d.value.Swap(&value); v != nil {
+		old = *v
+	}
+
+	for _, fn := range d.subscribers {
+		fn(old, value)
+	}
+}
+
+// Subscribe registers a function called every time the value is set, with
+// the previous and the new value. The calls are serialized.
+func (d *Dynamic[T]) Subscribe(fn func(old, new T)) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.subscribers = append(d.subscribers, fn)
+}
+
+func (d *Dynamic[T]) newValue() interface{} {
+	return new(T)
+}
+
+func (d *Dynamic[T]) load() interface{} {
+	return d.Load()
+}
+
+func (d *Dynamic[T]) store(value interface{}) {
+	v, _ := value.(T) // A nil interface isn't a T.
+	d.Store(v)
+}
+
+// Return the value pointed to by res, unwrapping the Dynamic values.
+func valueOf(res interface{}) interface{} {
+	if d, ok := res.(dynamic); ok {
+		return d.load()
+	}
+	return reflect.ValueOf(res).Elem().Interface()
+}
@@ -0,0 +1,93 @@
+package zconfig
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDynamic(t *testing.T) {
+	var d Dynamic[int]
+	if d.Load() != 0 {
+		t.Fatalf("unexpected initial value %d", d.Load())
+	}
+
+	var calls [][2]int
+	d.Subscribe(func(old, new int) {
+		calls = append(calls, [2]int{old, new})
+	})
+
+	d.Store(1)
+	d.Store(2)
+	if d.Load() != 2 {
+		t.Fatalf("unexpected value %d", d.Load())
+	}
+	if !reflect.DeepEqual(calls, [][2]int{{0, 1}, {1, 2}}) {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+}
+
+func TestDynamicReload(t *testing.T) {
+	var provider = TestProvider{name: "test", values: map[string]string{
+		"timeout": "1s",
+	}}
+
+	var repository Repository
+	repository.AddProviders(provider)
+	repository.AddParsers(ParseString)
+
+	p := NewProcessor(repository.Hook)
+	p.Repository = &repository
+
+	var s struct {
+		Timeout Dynamic[time.Duration]  `key:"timeout"`
+		Level   *Dynamic[string]        `key:"level" default:"info" enum:"debug,info"`
+		Other   Dynamic[map[string]int] // Not configurable.
+	}
+
+	err := p.Check(&s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = p.Process(context.Background(), &s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s.Timeout.Load() != time.Second || s.Level.Load() != "info" {
+		t.Fatalf("unexpected values %s, %s", s.Timeout.Load(), s.Level.Load())
+	}
+
+	var changed []time.Duration
+	s.Timeout.Subscribe(func(old, new time.Duration) {
+		changed = append(changed, old, new)
+	})
+
+	provider.values["timeout"] = "2s"
+	provider.values["level"] = "debug"
+	changes, err := p.Reload(context.Background(), &s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(changes) != 2 || !changes[0].Applied || !changes[1].Applied {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+	if changes[0].Old != time.Second || changes[0].New != 2*time.Second {
+		t.Fatalf("unexpected change %+v", changes[0])
+	}
+	if s.Timeout.Load() != 2*time.Second || s.Level.Load() != "debug" {
+		t.Fatalf("unexpected values %s, %s", s.Timeout.Load(), s.Level.Load())
+	}
+	if !reflect.DeepEqual(changed, []time.Duration{time.Second, 2 * time.Second}) {
+		t.Fatalf("unexpected notifications %v", changed)
+	}
+
+	provider.values["level"] = "trace"
+	_, err = p.Reload(context.Background(), &s)
+	if err == nil {
+		t.Fatal("expected an error for a value out of the enum, got nil")
+	}
+}
@@ -3,6 +3,8 @@ package zconfig
 import (
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 )
 
 const (
@@ -11,8 +13,20 @@ const (
 	TagKey         = "key"
 	TagDefault     = "default"
 	TagDescription = "description"
+	TagSecret      = "secret"
+	TagSources     = "sources"
+	TagEnum        = "enum"
+	TagInitTimeout = "init-timeout"
+	TagInitRetry   = "init-retry"
+	TagAfter       = "after"
+	TagEnabled     = "enabled"
+	TagReload      = "reload"
+	TagDeprecated  = "deprecated"
 )
 
+// Redacted is displayed in place of the value of secret fields.
+const Redacted = "***"
+
 type Field struct {
 	Value     reflect.Value
 	Path      string
@@ -26,6 +40,14 @@ type Field struct {
 	Provider         string
 	Configurable     bool
 	ConfigurationKey string
+	Initialized      bool
+
+	// Index of the layer of the field in the resolved dependency graph.
+	layer int
+
+	// Boolean field enabling the field and its children, from the enabled
+	// tag.
+	enabler *Field
 }
 
 func (f *Field) Inject(s *Field) (err error) {
@@ -54,3 +76,118 @@ func (f *Field) IsLeaf() bool {
 
 	return f.Value.Type().Elem().Kind() != reflect.Struct
 }
+
+// IsSecret returns whether the field holds a secret value, like a password,
+// that must never be displayed.
+func (f *Field) IsSecret() bool {
+	secret, _ := strconv.ParseBool(f.Tags.Get(TagSecret))
+	return secret
+}
+
+// IsReloadable returns whether the value of the field can be changed while the
+// service is running, which is the case if the field is a Dynamic, or if the
+// field or one of its ancestors has a true reload tag.
+func (f *Field) IsReloadable() bool {
+	if _, ok := pointer(f.Value).Interface().(dynamic); ok {
+		return true
+	}
+
+	for a := f; a != nil; a = a.Parent {
+		if reload, _ := strconv.ParseBool(a.Tags.Get(TagReload)); reload {
+			return true
+		}
+	}
+	return false
+}
+
+// Sources returns the names of the providers allowed to supply the value of
+// the field, or nil if any provider is allowed.
+func (f *Field) Sources() (sources []string) {
+	return splitTag(f.Tags, TagSources)
+}
+
+// Enum returns the raw values allowed for the field, or nil if any value is
+// allowed.
+func (f *Field) Enum() (values []string) {
+	return splitTag(f.Tags, TagEnum)
+}
+
+// After returns the names of the sibling fields that must be processed before
+// the field, or nil if there is none.
+func (f *Field) After() (names []string) {
+	return splitTag(f.Tags, TagAfter)
+}
+
+// Return the sibling of the field with the given name, or nil if there is
+// none.
+func (f *Field) sibling(name string) *Field {
+	if f.Parent == nil {
+		return nil
+	}
+
+	for _, c := range f.Parent.Children {
+		if c != f && c.Path == f.Parent.Path+"."+name {
+			return c
+		}
+	}
+	return nil
+}
+
+// IsEnabled returns false if the field belongs to a branch disabled by the
+// value of the key given in an enabled tag.
+func (f *Field) IsEnabled() bool {
+	for a := f; a != nil; a = a.Parent {
+		if a.enabler == nil || a.enabler == f {
+			continue
+		}
+
+		v := a.enabler.Value
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		if !v.Bool() {
+			return false
+		}
+	}
+	return true
+}
+
+// Condition returns the configuration key enabling the field, or an empty
+// string if the field is always enabled.
+func (f *Field) Condition() string {
+	for a := f; a != nil; a = a.Parent {
+		if a.enabler != nil && a.enabler != f {
+			return a.enabler.ConfigurationKey
+		}
+	}
+	return ""
+}
+
+// Return whether the field is live, i.e. neither disabled nor deferred by a
+// Lazy not initialized yet.
+func (f *Field) active() bool {
+	return f.IsEnabled() && !f.deferred()
+}
+
+// Return a pointer to a new zero value of the type of the field, suitable to
+// be given to a parser.
+func (f *Field) newValue() interface{} {
+	typ := f.Value.Type()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return reflect.New(typ).Interface()
+}
+
+// Split a comma-separated tag value, or return nil if the tag isn't defined.
+func splitTag(tags reflect.StructTag, name string) (values []string) {
+	tag, ok := tags.Lookup(name)
+	if !ok {
+		return nil
+	}
+
+	for _, value := range strings.Split(tag, ",") {
+		values = append(values, strings.TrimSpace(value))
+	}
+	return values
+}
@@ -0,0 +1,417 @@
+package zconfig
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultPollInterval = 2 * time.Second
+
+// A FileProvider retrieves the keys from a configuration file, either a JSON
+// or YAML file whose nested objects match the dotted keys, or an env file
+// holding one VARIABLE=value per line, named after the keys the same way as
+// the environment variables. The format is guessed from the extension of the
+// file: .yaml or .yml for YAML, .env for env files, and JSON otherwise.
+type FileProvider struct {
+	Path string
+
+	// PollInterval is the interval at which Watch checks the file for
+	// changes. Zero means two seconds.
+	PollInterval time.Duration
+
+	lock   sync.RWMutex
+	env    bool
+	values map[string]string
+	loaded fileState
+}
+
+// NewFileProvider returns a provider retrieving the keys from the file at the
+// given path, which is read immediately.
+func NewFileProvider(path string) (*FileProvider, error) {
+	p := &FileProvider{Path: path}
+	err := p.Load()
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Load reads the file again, replacing the values previously read. The
+// values are left untouched if the file can't be read or decoded.
+func (p *FileProvider) Load() error {
+	// Get the state first so a change happening while the file is read
+	// is detected by Watch.
+	state, _ := p.state()
+
+	raw, err := os.ReadFile(p.Path)
+	if err != nil {
+		return fmt.Errorf("reading file %s: %w", p.Path, err)
+	}
+
+	var (
+		ext    = strings.ToLower(filepath.Ext(p.Path))
+		env    = ext == ".env"
+		values map[string]string
+	)
+	switch ext {
+	case ".env":
+		values, err = decodeEnvFile(raw)
+	case ".yaml", ".yml":
+		values, err = decodeYAMLFile(raw)
+	default:
+		values, err = decodeJSONFile(raw)
+	}
+	if err != nil {
+		return fmt.Errorf("decoding file %s: %w", p.Path, err)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.env = env
+	p.values = values
+	p.loaded = state
+	return nil
+}
+
+// Retrieve the value of the key from the values last read.
+func (p *FileProvider) Retrieve(key string) (value interface{}, found bool, err error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.env {
+		key = Env.FormatKey(key)
+	}
+	value, found = p.values[key]
+	return value, found, nil
+}
+
+// Name of the provider.
+func (*FileProvider) Name() string {
+	return "file"
+}
+
+// Priority of the provider, after the command-line arguments and the
+// environment variables.
+func (*FileProvider) Priority() int {
+	return 3
+}
+
+// Watch polls the file for changes until the context is done. The file is
+// read again when its size, modification time or resolved path changes, the
+// latter allowing to detect the swap of the symbolic links used by
+// Kubernetes to update a mounted ConfigMap, and a value is sent if any of its
+// values changed. The errors are reported through the DefaultLogger.
+func (p *FileProvider) Watch(ctx context.Context) <-chan struct{} {
+	interval := p.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			current, err := p.state()
+			if err != nil {
+				DefaultLogger.Printf("watching file %s: %s", p.Path, err)
+				continue
+			}
+
+			p.lock.Lock()
+			previous, loaded := p.values, p.loaded
+			p.loaded = current // Report the errors once per change.
+			p.lock.Unlock()
+
+			if current == loaded {
+				continue
+			}
+
+			err = p.Load()
+			if err != nil {
+				DefaultLogger.Printf("watching file %s: %s", p.Path, err)
+				continue
+			}
+
+			p.lock.RLock()
+			changed := !reflect.DeepEqual(previous, p.values)
+			p.lock.RUnlock()
+
+			if changed {
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return changes
+}
+
+// The state of a file used to detect its changes.
+type fileState struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (p *FileProvider) state() (state fileState, err error) {
+	state.path, err = filepath.EvalSymlinks(p.Path)
+	if err != nil {
+		return state, err
+	}
+
+	info, err := os.Stat(state.path)
+	if err != nil {
+		return state, err
+	}
+	state.size = info.Size()
+	state.modTime = info.ModTime()
+	return state, nil
+}
+
+// Decode a JSON file into values indexed by dotted keys. The numbers and
+// booleans are kept in their textual form, and the arrays are joined by
+// commas.
+func decodeJSONFile(raw []byte) (map[string]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var doc map[string]interface{}
+	err := decoder.Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	err = flattenValues(values, "", doc)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func flattenValues(values map[string]string, key string, raw interface{}) error {
+	switch raw := raw.(type) {
+	case map[string]interface{}:
+		for k, v := range raw {
+			if key != "" {
+				k = key + "." + k
+			}
+			err := flattenValues(values, k, v)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		items := make([]string, 0, len(raw))
+		for _, v := range raw {
+			item, err := scalarValue(v)
+			if err != nil {
+				return fmt.Errorf("key %s: %w", key, err)
+			}
+			items = append(items, item)
+		}
+		values[key] = strings.Join(items, ",")
+		return nil
+	case nil:
+		return nil
+	default:
+		value, err := scalarValue(raw)
+		if err != nil {
+			return fmt.Errorf("key %s: %w", key, err)
+		}
+		values[key] = value
+		return nil
+	}
+}
+
+func scalarValue(raw interface{}) (string, error) {
+	switch raw := raw.(type) {
+	case string:
+		return raw, nil
+	case json.Number:
+		return raw.String(), nil
+	case bool:
+		return strconv.FormatBool(raw), nil
+	default:
+		return "", fmt.Errorf("unexpected value of type %T", raw)
+	}
+}
+
+// Decode a YAML file into values indexed by dotted keys. The scalars are kept
+// in their textual form, and the sequences are joined by commas.
+func decodeYAMLFile(raw []byte) (map[string]string, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(raw, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	if len(doc.Content) == 0 {
+		return values, nil // Empty document.
+	}
+
+	err = flattenNode(values, "", doc.Content[0])
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func flattenNode(values map[string]string, key string, node *yaml.Node) error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k := node.Content[i].Value
+			if key != "" {
+				k = key + "." + k
+			}
+			err := flattenNode(values, k, node.Content[i+1])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if item.Kind == yaml.AliasNode {
+				item = item.Alias
+			}
+			if item.Kind != yaml.ScalarNode {
+				return fmt.Errorf("key %s: unexpected nested value at line %d", key, item.Line)
+			}
+			items = append(items, item.Value)
+		}
+		values[key] = strings.Join(items, ",")
+		return nil
+	case yaml.ScalarNode:
+		if node.ShortTag() == "!!null" {
+			return nil
+		}
+		values[key] = node.Value
+		return nil
+	default:
+		return fmt.Errorf("key %s: unexpected value at line %d", key, node.Line)
+	}
+}
+
+// Decode an env file, made of VARIABLE=value lines. Empty lines and lines
+// starting with a # are ignored, and the values may be quoted.
+func decodeEnvFile(raw []byte) (map[string]string, error) {
+	values := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: missing =", n)
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			if value[0] == '"' {
+				unquoted, err := strconv.Unquote(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", n, err)
+				}
+				value = unquoted
+			} else {
+				value = value[1 : len(value)-1]
+			}
+		}
+
+		values[strings.TrimSpace(name)] = value
+	}
+
+	return values, scanner.Err()
+}
+
+// A SignalWatcher is a provider signaling a change every time the process
+// receives one of its signals, for the daemons expected to reload their
+// configuration on SIGHUP. If the wrapped provider has a Load method, like
+// the FileProvider, it is called before the change is signaled.
+type SignalWatcher struct {
+	Provider
+	Signals []os.Signal
+}
+
+// NewSignalWatcher wraps the given provider so it signals a change when the
+// process receives one of the given signals, or SIGHUP if none is given.
+func NewSignalWatcher(p Provider, signals ...os.Signal) *SignalWatcher {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+	return &SignalWatcher{Provider: p, Signals: signals}
+}
+
+// Watch the signals until the context is done.
+func (w *SignalWatcher) Watch(ctx context.Context) <-chan struct{} {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, w.Signals...)
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		defer signal.Stop(signals)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+			}
+
+			if l, ok := w.Provider.(interface{ Load() error }); ok {
+				err := l.Load()
+				if err != nil {
+					DefaultLogger.Printf("reloading provider %s: %s", w.Name(), err)
+					continue
+				}
+			}
+
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return changes
+}
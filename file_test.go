@@ -0,0 +1,204 @@
+package zconfig
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, tCase := range map[string]struct {
+		content  string
+		expected map[string]string
+	}{
+		"config.json": {
+			content: `{"db": {"host": "localhost", "port": 5432, "tls": true}, "tags": ["a", "b"], "none": null}`,
+			expected: map[string]string{
+				"db.host": "localhost",
+				"db.port": "5432",
+				"db.tls":  "true",
+				"tags":    "a,b",
+			},
+		},
+		"config.yaml": {
+			content: "db:\n  host: localhost\n  port: 5432\n  tls: true\n  timeout: 1.50\ntags: [a, b]\nnone: ~\n",
+			expected: map[string]string{
+				"db.host":    "localhost",
+				"db.port":    "5432",
+				"db.tls":     "true",
+				"db.timeout": "1.50",
+				"tags":       "a,b",
+			},
+		},
+		"config.env": {
+			content: "# database\nDB_HOST=localhost\nexport DB_PORT = 5432\n\nDB_NAME=\"my \\\"db\\\"\"\nDB_USER='user'\n",
+			expected: map[string]string{
+				"db.host": "localhost",
+				"db.port": "5432",
+				"db.name": `my "db"`,
+				"db.user": "user",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			err := os.WriteFile(path, []byte(tCase.content), 0o600)
+			if err != nil {
+				t.Fatalf("writing file: %s", err)
+			}
+
+			p, err := NewFileProvider(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for key, expected := range tCase.expected {
+				value, found, err := p.Retrieve(key)
+				if err != nil || !found || value != expected {
+					t.Errorf("retrieving %s: wanted %q, got %v, %t, %v", key, expected, value, found, err)
+				}
+			}
+
+			_, found, _ := p.Retrieve("missing")
+			if found {
+				t.Errorf("unexpected missing key found")
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		path := filepath.Join(dir, "invalid.json")
+		err := os.WriteFile(path, []byte(`{"a": `), 0o600)
+		if err != nil {
+			t.Fatalf("writing file: %s", err)
+		}
+
+		_, err = NewFileProvider(path)
+		if err == nil {
+			t.Fatalf("expected an error, got nil")
+		}
+	})
+}
+
+func TestFileProviderWatch(t *testing.T) {
+	// Mimic the layout of a mounted ConfigMap, whose data directory is
+	// swapped atomically by replacing a symbolic link.
+	dir := t.TempDir()
+	for _, name := range []string{"v1", "v2"} {
+		err := os.Mkdir(filepath.Join(dir, name), 0o700)
+		if err != nil {
+			t.Fatalf("creating directory: %s", err)
+		}
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "v1", "config.json"), []byte(`{"level": "info"}`), 0o600)
+	if err != nil {
+		t.Fatalf("writing file: %s", err)
+	}
+	err = os.Symlink("v1", filepath.Join(dir, "data"))
+	if err != nil {
+		t.Fatalf("creating link: %s", err)
+	}
+	err = os.Symlink(filepath.Join("data", "config.json"), filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatalf("creating link: %s", err)
+	}
+
+	p, err := NewFileProvider(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.PollInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := p.Watch(ctx)
+
+	// Same size but different content, in another directory.
+	err = os.WriteFile(filepath.Join(dir, "v2", "config.json"), []byte(`{"level": "warn"}`), 0o600)
+	if err != nil {
+		t.Fatalf("writing file: %s", err)
+	}
+	err = os.Symlink("v2", filepath.Join(dir, "data.tmp"))
+	if err != nil {
+		t.Fatalf("creating link: %s", err)
+	}
+	err = os.Rename(filepath.Join(dir, "data.tmp"), filepath.Join(dir, "data"))
+	if err != nil {
+		t.Fatalf("swapping link: %s", err)
+	}
+
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("change not detected")
+	}
+
+	value, _, _ := p.Retrieve("level")
+	if value != "warn" {
+		t.Fatalf("unexpected value %v", value)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-changes:
+		if ok {
+			t.Fatal("unexpected change")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed")
+	}
+}
+
+func TestSignalWatcher(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.env")
+	err := os.WriteFile(path, []byte("LEVEL=info\n"), 0o600)
+	if err != nil {
+		t.Fatalf("writing file: %s", err)
+	}
+
+	p, err := NewFileProvider(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w := NewSignalWatcher(p)
+	if !reflect.DeepEqual(w.Signals, []os.Signal{syscall.SIGHUP}) {
+		t.Fatalf("unexpected signals %v", w.Signals)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := w.Watch(ctx)
+
+	err = os.WriteFile(path, []byte("LEVEL=debug\n"), 0o600)
+	if err != nil {
+		t.Fatalf("writing file: %s", err)
+	}
+
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("finding process: %s", err)
+	}
+	err = process.Signal(syscall.SIGHUP)
+	if err != nil {
+		t.Fatalf("sending signal: %s", err)
+	}
+
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("signal not received")
+	}
+
+	value, _, _ := w.Retrieve("level")
+	if value != "debug" {
+		t.Fatalf("unexpected value %v", value)
+	}
+}
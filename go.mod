@@ -1,8 +1,11 @@
 module github.com/synthesio/zconfig/v2
 
-go 1.18
+go 1.21
 
-require github.com/hchargois/flexwriter v1.2.0
+require (
+	github.com/hchargois/flexwriter v1.2.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/MichaelMure/go-term-text v0.3.1 // indirect
@@ -0,0 +1,128 @@
+package zconfig
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// HealthChecker is the interface implemented by the fields able to report
+// their health, typically by pinging the service they are connected to.
+type HealthChecker interface {
+	Health(context.Context) error
+}
+
+// Used for type comparison.
+var typeHealthChecker = reflect.TypeOf((*HealthChecker)(nil)).Elem()
+
+// CheckHealth checks concurrently the health of every field implementing
+// HealthChecker in the services processed and not yet shut down, except those
+// disabled or wrapped in a Lazy not initialized yet, and returns
+// the result of each check by field path. A nil error means the field is
+// healthy. The paths of the fields of the service processed first start with
+// "$", and those of the following services with "$2", "$3" and so on, in
+// processing order, so that services having the same fields don't collide.
+func (p *Processor) CheckHealth(ctx context.Context) map[string]error {
+	p.lock.Lock()
+	var (
+		fields []*Field
+		keys   = make(map[*Field]string)
+	)
+	for _, processed := range p.processed {
+		for _, field := range processed.fields {
+			fields = append(fields, field)
+			keys[field] = healthKey(processed.id, field.Path)
+		}
+	}
+	p.lock.Unlock()
+
+	var (
+		status = make(map[string]error)
+		lock   sync.Mutex
+		wg     sync.WaitGroup
+	)
+	for _, field := range fields {
+		if !field.active() || !field.Value.Type().Implements(typeHealthChecker) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(field *Field) {
+			defer wg.Done()
+
+			err := field.Value.Interface().(HealthChecker).Health(ctx)
+
+			lock.Lock()
+			defer lock.Unlock()
+			status[keys[field]] = err
+		}(field)
+	}
+	wg.Wait()
+
+	return status
+}
+
+// Return the key of the status of a field of the given processed struct.
+func healthKey(id int, path string) string {
+	if id == 1 {
+		return path
+	}
+	return "$" + strconv.Itoa(id) + strings.TrimPrefix(path, "$")
+}
+
+// HealthHandler returns an HTTP handler serving the health of the processed
+// services on two routes: /healthz is a liveness check always responding 200
+// as long as the process serves requests, so a failing dependency doesn't get
+// it restarted, and /readyz responds 200 if a service is processed and not
+// shut down yet and every field is healthy, 503 otherwise, with a JSON
+// document detailing the status of every field.
+func (p *Processor) HealthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, healthReport{Status: "ok"})
+	})
+	mux.HandleFunc("/readyz", p.serveReadiness)
+	return mux
+}
+
+type healthReport struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func (p *Processor) serveReadiness(w http.ResponseWriter, r *http.Request) {
+	var report = healthReport{Status: "ok", Checks: make(map[string]string)}
+
+	for path, err := range p.CheckHealth(r.Context()) {
+		report.Checks[path] = "ok"
+		if err != nil {
+			report.Checks[path] = err.Error()
+			report.Status = "unhealthy"
+		}
+	}
+
+	p.lock.Lock()
+	processed := len(p.processed) != 0
+	p.lock.Unlock()
+
+	if !processed {
+		report.Status = "not ready"
+	}
+
+	writeHealth(w, report)
+}
+
+func writeHealth(w http.ResponseWriter, report healthReport) {
+	code := http.StatusOK
+	if report.Status != "ok" {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(report)
+}
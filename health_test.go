@@ -0,0 +1,136 @@
+package zconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type healthTest struct {
+	err error
+}
+
+func (h *healthTest) Health(ctx context.Context) error {
+	return h.err
+}
+
+func TestProcessorHealth(t *testing.T) {
+	var s = struct {
+		Healthy   *healthTest
+		Unhealthy *healthTest
+		Other     *SimpleDependency
+	}{
+		Healthy:   &healthTest{},
+		Unhealthy: &healthTest{err: errors.New("connection refused")},
+	}
+
+	p := NewProcessor()
+	handler := p.HealthHandler()
+
+	get := func(t *testing.T, path string) (int, healthReport) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		var report healthReport
+		err := json.NewDecoder(rec.Body).Decode(&report)
+		if err != nil {
+			t.Fatalf("decoding report: %s", err)
+		}
+		return rec.Code, report
+	}
+
+	t.Run("not processed", func(t *testing.T) {
+		code, _ := get(t, "/healthz")
+		if code != http.StatusOK {
+			t.Fatalf("unexpected liveness status %d", code)
+		}
+
+		code, report := get(t, "/readyz")
+		if code != http.StatusServiceUnavailable || report.Status != "not ready" {
+			t.Fatalf("unexpected readiness status %d: %+v", code, report)
+		}
+	})
+
+	err := p.Process(context.Background(), &s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("check", func(t *testing.T) {
+		status := p.CheckHealth(context.Background())
+		if len(status) != 2 {
+			t.Fatalf("unexpected status: %v", status)
+		}
+		if status["$.Healthy"] != nil {
+			t.Fatalf("unexpected error for healthy field: %v", status["$.Healthy"])
+		}
+		if status["$.Unhealthy"] == nil {
+			t.Fatal("expected an error for unhealthy field")
+		}
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		// A failing dependency doesn't affect the liveness.
+		code, report := get(t, "/healthz")
+		if code != http.StatusOK || report.Status != "ok" || report.Checks != nil {
+			t.Fatalf("unexpected liveness status %d: %+v", code, report)
+		}
+
+		code, report = get(t, "/readyz")
+		if code != http.StatusServiceUnavailable || report.Status != "unhealthy" {
+			t.Fatalf("unexpected readiness status %d: %+v", code, report)
+		}
+
+		if report.Checks["$.Healthy"] != "ok" || report.Checks["$.Unhealthy"] != "connection refused" {
+			t.Fatalf("unexpected checks: %v", report.Checks)
+		}
+
+		s.Unhealthy.err = nil
+		code, _ = get(t, "/readyz")
+		if code != http.StatusOK {
+			t.Fatalf("unexpected readiness status %d", code)
+		}
+	})
+
+	t.Run("shutdown", func(t *testing.T) {
+		err := p.Shutdown(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		code, _ := get(t, "/readyz")
+		if code != http.StatusServiceUnavailable {
+			t.Fatalf("unexpected readiness status %d", code)
+		}
+	})
+}
+
+func TestProcessorHealthStructs(t *testing.T) {
+	type service struct {
+		DB *healthTest
+	}
+	healthy := service{DB: &healthTest{}}
+	unhealthy := service{DB: &healthTest{err: errors.New("down")}}
+
+	p := NewProcessor()
+	for _, s := range []*service{&healthy, &unhealthy} {
+		err := p.Process(context.Background(), s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	status := p.CheckHealth(context.Background())
+	if len(status) != 2 || status["$.DB"] != nil || status["$2.DB"] == nil {
+		t.Fatalf("unexpected status: %v", status)
+	}
+
+	rec := httptest.NewRecorder()
+	p.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected readiness status %d", rec.Code)
+	}
+}
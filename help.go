@@ -0,0 +1,77 @@
+package zconfig
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// HelpVersion is the version of the document printed by --help=json, which is
+// incremented on incompatible changes.
+const HelpVersion = 1
+
+// The document printed by --help=json, describing the configuration keys of
+// a program for external tools.
+type helpDocument struct {
+	Version int       `json:"version"`
+	Keys    []helpKey `json:"keys"`
+}
+
+type helpKey struct {
+	Key         string   `json:"key"`
+	Flag        string   `json:"flag"`
+	Env         string   `json:"env"`
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Default     *string  `json:"default,omitempty"`
+	Required    bool     `json:"required"`
+	Secret      bool     `json:"secret"`
+	Enum        []string `json:"enum,omitempty"`
+	Condition   string   `json:"condition,omitempty"`
+	Deprecated  *string  `json:"deprecated,omitempty"`
+}
+
+// Write the description of the configurable fields as a JSON document, sorted
+// by key. The default values of secret fields are redacted.
+func writeJSONUsage(w io.Writer, fields []*Field) error {
+	doc := helpDocument{Version: HelpVersion, Keys: []helpKey{}}
+	for _, f := range fields {
+		if !f.Configurable {
+			continue
+		}
+
+		key := helpKey{
+			Key:       f.ConfigurationKey,
+			Flag:      "--" + f.ConfigurationKey,
+			Env:       Env.FormatKey(f.ConfigurationKey),
+			Type:      typeName(f),
+			Secret:    f.IsSecret(),
+			Enum:      f.Enum(),
+			Condition: f.Condition(),
+		}
+		key.Description, _ = f.Tags.Lookup(TagDescription)
+
+		if def, ok := f.Tags.Lookup(TagDefault); ok {
+			if key.Secret {
+				def = Redacted
+			}
+			key.Default = &def
+		} else {
+			key.Required = true
+		}
+
+		if message, ok := f.Tags.Lookup(TagDeprecated); ok {
+			key.Deprecated = &message
+		}
+
+		doc.Keys = append(doc.Keys, key)
+	}
+
+	sort.Slice(doc.Keys, func(a, b int) bool {
+		return doc.Keys[a].Key < doc.Keys[b].Key
+	})
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
@@ -0,0 +1,55 @@
+package zconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONUsage(t *testing.T) {
+	_, fields, err := prepare(new(struct {
+		Addr     string        `key:"addr" description:"address to bind" default:":80"`
+		Password string        `key:"password" default:"hunter2" secret:"true"`
+		Timeout  time.Duration `key:"timeout"`
+		Format   string        `key:"format" enum:"json,text" default:"json" deprecated:"use --log.format"`
+		Tracing  struct {
+			Enabled  bool   `key:"enabled" default:"false"`
+			Endpoint string `key:"endpoint"`
+		} `key:"tracing" enabled:"tracing.enabled"`
+	}))
+	if err != nil {
+		t.Fatalf("preparing struct: %s", err)
+	}
+
+	out := captureStdout(t, func() { DefaultUsageVal("json", fields) })
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("secret leaked in usage")
+	}
+
+	var actual, expected interface{}
+	err = json.Unmarshal([]byte(out), &actual)
+	if err != nil {
+		t.Fatalf("invalid document: %s\n%s", err, out)
+	}
+
+	err = json.Unmarshal([]byte(`{
+		"version": 1,
+		"keys": [
+			{"key": "addr", "flag": "--addr", "env": "ADDR", "type": "string", "description": "address to bind", "default": ":80", "required": false, "secret": false},
+			{"key": "format", "flag": "--format", "env": "FORMAT", "type": "string", "default": "json", "required": false, "secret": false, "enum": ["json", "text"], "deprecated": "use --log.format"},
+			{"key": "password", "flag": "--password", "env": "PASSWORD", "type": "string", "default": "***", "required": false, "secret": true},
+			{"key": "timeout", "flag": "--timeout", "env": "TIMEOUT", "type": "time.Duration", "required": true, "secret": false},
+			{"key": "tracing.enabled", "flag": "--tracing.enabled", "env": "TRACING_ENABLED", "type": "bool", "default": "false", "required": false, "secret": false},
+			{"key": "tracing.endpoint", "flag": "--tracing.endpoint", "env": "TRACING_ENDPOINT", "type": "string", "required": true, "secret": false, "condition": "tracing.enabled"}
+		]
+	}`), &expected)
+	if err != nil {
+		t.Fatalf("invalid expected document: %s", err)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected document:\n%s", out)
+	}
+}
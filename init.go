@@ -3,7 +3,11 @@ package zconfig
 import (
 	"context"
 	"fmt"
+	"math"
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Initializable interface {
@@ -18,29 +22,185 @@ type initializableDeprecated interface {
 var typeInitializable = reflect.TypeOf((*Initializable)(nil)).Elem()
 var typeInitializableDeprecated = reflect.TypeOf((*initializableDeprecated)(nil)).Elem()
 
+// Initialize a field via the Initializable interface. The init-timeout tag
+// bounds the duration of each attempt with a context deadline, and the
+// init-retry tag allows retrying failed attempts, each retry being logged
+// through the DefaultLogger. The fields wrapped in a Lazy are skipped, their
+// initialization being deferred to its first use, as well as the fields
+// disabled by an enabled tag.
 func Initialize(ctx context.Context, field *Field) error {
-	// Not initializable, nothing to do.
-	if field.Value.Type().Implements(typeInitializable) {
+	if field.lazyAncestor() != nil || !field.IsEnabled() {
+		return nil
+	}
 
-		// Initialize the element itself via the interface.
-		err := field.Value.Interface().(Initializable).Init(ctx)
-		if err != nil {
-			return fmt.Errorf("initializing field: %w", err)
-		}
+	return initialize(ctx, field)
+}
 
+func initialize(ctx context.Context, field *Field) error {
+	if l, ok := field.lazy(); ok {
+		l.setup(field)
+		field.Initialized = true
 		return nil
 	}
 
-	if field.Value.Type().Implements(typeInitializableDeprecated) {
+	var init func(context.Context) error
+	switch {
+	case field.Value.Type().Implements(typeInitializable):
+		init = field.Value.Interface().(Initializable).Init
+	case field.Value.Type().Implements(typeInitializableDeprecated):
+		// The deprecated interface can't honour the timeout.
+		init = func(context.Context) error {
+			return field.Value.Interface().(initializableDeprecated).Init()
+		}
+	default:
+		// Not initializable, nothing to do.
+		return nil
+	}
 
-		// Initialize the element itself via the interface.
-		err := field.Value.Interface().(initializableDeprecated).Init()
-		if err != nil {
+	timeout, err := parseTimeout(field.Tags.Get(TagInitTimeout))
+	if err != nil {
+		return fmt.Errorf("initializing field: invalid %s tag: %w", TagInitTimeout, err)
+	}
+
+	retry, err := parseRetry(field.Tags.Get(TagInitRetry))
+	if err != nil {
+		return fmt.Errorf("initializing field: invalid %s tag: %w", TagInitRetry, err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = initAttempt(ctx, init, timeout)
+		if err == nil {
+			break
+		}
+
+		if attempt >= retry.attempts || ctx.Err() != nil {
 			return fmt.Errorf("initializing field: %w", err)
 		}
 
-		return nil
+		delay := retry.delay(attempt)
+		DefaultLogger.Printf("initializing field %s: attempt %d/%d failed: %s, retrying in %s", field.Path, attempt, retry.attempts, err, delay)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return fmt.Errorf("initializing field: %w", err)
+		}
 	}
 
+	field.Initialized = true
 	return nil
 }
+
+// Execute a single initialization attempt, with a deadline if a timeout is
+// given.
+func initAttempt(ctx context.Context, init func(context.Context) error, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return init(ctx)
+}
+
+// Parse the value of an init-timeout tag. An empty value means no timeout.
+func parseTimeout(raw string) (timeout time.Duration, err error) {
+	if raw == "" {
+		return 0, nil
+	}
+
+	timeout, err = time.ParseDuration(raw)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("non positive timeout %s", raw)
+	}
+	return timeout, nil
+}
+
+const defaultRetryDelay = 100 * time.Millisecond
+
+// A retryPolicy defines how many times a field initialization is attempted
+// and how long to wait between the attempts.
+type retryPolicy struct {
+	attempts    int
+	exponential bool
+	min, max    time.Duration
+}
+
+// Return the delay to wait after the given failed attempt.
+func (r retryPolicy) delay(attempt int) time.Duration {
+	if !r.exponential {
+		return r.min
+	}
+
+	// Without maximum, the delay still can't exceed the largest duration.
+	limit := r.max
+	if limit == 0 {
+		limit = math.MaxInt64
+	}
+
+	delay := r.min
+	for i := 1; i < attempt && delay < limit; i++ {
+		if delay > limit/2 {
+			delay = limit
+		} else {
+			delay *= 2
+		}
+	}
+	return delay
+}
+
+// Parse the value of an init-retry tag, in the form
+// `attempts[,const|exp[,delay[..max]]]`. An empty value means a single
+// attempt.
+func parseRetry(raw string) (retry retryPolicy, err error) {
+	retry = retryPolicy{attempts: 1, min: defaultRetryDelay}
+	if raw == "" {
+		return retry, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	if len(parts) > 3 {
+		return retry, fmt.Errorf("too many parts in %q", raw)
+	}
+
+	retry.attempts, err = strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return retry, fmt.Errorf("invalid number of attempts: %w", err)
+	}
+	if retry.attempts < 1 {
+		return retry, fmt.Errorf("invalid number of attempts %d", retry.attempts)
+	}
+
+	if len(parts) > 1 {
+		switch strategy := strings.TrimSpace(parts[1]); strategy {
+		case "const":
+		case "exp":
+			retry.exponential = true
+		default:
+			return retry, fmt.Errorf("unknown strategy %q", strategy)
+		}
+	}
+
+	if len(parts) > 2 {
+		bounds := strings.SplitN(strings.TrimSpace(parts[2]), "..", 2)
+
+		retry.min, err = time.ParseDuration(bounds[0])
+		if err != nil {
+			return retry, fmt.Errorf("invalid delay: %w", err)
+		}
+
+		if len(bounds) == 2 {
+			retry.max, err = time.ParseDuration(bounds[1])
+			if err != nil {
+				return retry, fmt.Errorf("invalid maximum delay: %w", err)
+			}
+			if retry.max < retry.min {
+				return retry, fmt.Errorf("maximum delay %s lower than delay %s", retry.max, retry.min)
+			}
+		}
+	}
+
+	return retry, nil
+}
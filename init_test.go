@@ -2,7 +2,11 @@ package zconfig
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 )
 
 type initTest struct {
@@ -47,3 +51,127 @@ func TestInitialize(t *testing.T) {
 		}
 	})
 }
+
+type flakyInitTest struct {
+	failures int
+	attempts int
+}
+
+func (f *flakyInitTest) Init(ctx context.Context) error {
+	f.attempts++
+	if f.attempts <= f.failures {
+		return errors.New("not ready")
+	}
+	return nil
+}
+
+type slowInitTest struct{}
+
+func (slowInitTest) Init(ctx context.Context) error {
+	select {
+	case <-time.After(time.Second):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type testLogger []string
+
+func (l *testLogger) Printf(format string, v ...interface{}) {
+	*l = append(*l, fmt.Sprintf(format, v...))
+}
+
+func TestInitializeRetry(t *testing.T) {
+	var logger testLogger
+	defaultLogger := DefaultLogger
+	DefaultLogger = &logger
+	defer func() { DefaultLogger = defaultLogger }()
+
+	t.Run("success", func(t *testing.T) {
+		logger = nil
+		s := struct {
+			Flaky *flakyInitTest `init-retry:"3,const,1ms"`
+		}{&flakyInitTest{failures: 2}}
+
+		err := NewProcessor(Initialize).Process(context.Background(), &s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if s.Flaky.attempts != 3 {
+			t.Fatalf("unexpected number of attempts: %d", s.Flaky.attempts)
+		}
+
+		if len(logger) != 2 || !strings.HasPrefix(logger[0], "initializing field $.Flaky: attempt 1/3 failed: not ready") {
+			t.Fatalf("unexpected logs: %v", logger)
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		s := struct {
+			Flaky *flakyInitTest `init-retry:"2,exp,1ms..2ms"`
+		}{&flakyInitTest{failures: 2}}
+
+		err := NewProcessor(Initialize).Process(context.Background(), &s)
+		if err == nil || !strings.Contains(err.Error(), "not ready") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if s.Flaky.attempts != 2 {
+			t.Fatalf("unexpected number of attempts: %d", s.Flaky.attempts)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		s := struct {
+			Slow *slowInitTest `init-timeout:"10ms"`
+		}{&slowInitTest{}}
+
+		err := NewProcessor(Initialize).Process(context.Background(), &s)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestParseRetry(t *testing.T) {
+	for raw, expected := range map[string]retryPolicy{
+		"":                 {attempts: 1, min: defaultRetryDelay},
+		"5":                {attempts: 5, min: defaultRetryDelay},
+		"5,const,1s":       {attempts: 5, min: time.Second},
+		"5,exp,100ms..5s":  {attempts: 5, exponential: true, min: 100 * time.Millisecond, max: 5 * time.Second},
+		" 3 , exp , 10ms ": {attempts: 3, exponential: true, min: 10 * time.Millisecond},
+	} {
+		actual, err := parseRetry(raw)
+		if err != nil {
+			t.Errorf("parseRetry(%q): unexpected error %v", raw, err)
+			continue
+		}
+		if actual != expected {
+			t.Errorf("parseRetry(%q): wanted %+v, got %+v", raw, expected, actual)
+		}
+	}
+
+	for _, raw := range []string{"a", "0", "5,linear", "5,exp,1s..1ms", "5,exp,1s,2s"} {
+		_, err := parseRetry(raw)
+		if err == nil {
+			t.Errorf("parseRetry(%q): should fail", raw)
+		}
+	}
+
+	policy := retryPolicy{attempts: 5, exponential: true, min: 100 * time.Millisecond, max: 300 * time.Millisecond}
+	for attempt, expected := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond} {
+		if actual := policy.delay(attempt + 1); actual != expected {
+			t.Errorf("delay(%d): wanted %s, got %s", attempt+1, expected, actual)
+		}
+	}
+
+	// Without maximum, the doubling must not overflow.
+	policy = retryPolicy{attempts: 100, exponential: true, min: 100 * time.Millisecond}
+	for attempt := 2; attempt <= 100; attempt++ {
+		if policy.delay(attempt) < policy.delay(attempt-1) {
+			t.Fatalf("delay(%d): decreasing delay %s", attempt, policy.delay(attempt))
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package zconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Lazy wraps a dependency whose initialization is deferred until its first
+// use. The wrapped value is configured normally, as if it were declared in
+// place of the Lazy field, but the Initialize hook skips it and its
+// children: they are initialized by the first call to Get instead.
+type Lazy[T any] struct {
+	// Value is the wrapped dependency, use Get to access it.
+	Value T
+
+	lock        sync.Mutex
+	done        atomic.Bool
+	field       *Field
+	initialized []*Field
+}
+
+// The lazy interface is used by the processor to identify Lazy fields.
+type lazy interface {
+	setup(*Field)
+	ready() bool
+	Close(context.Context) error
+}
+
+// Get returns the wrapped value, initializing it and its children on the
+// first call. If the initialization fails, the children already initialized
+// are closed and the next call will try again. Get is safe for concurrent
+// use.
+func (l *Lazy[T]) Get(ctx context.Context) (value T, err error) {
+	if l.done.Load() {
+		return l.Value, nil
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.done.Load() {
+		return l.Value, nil
+	}
+
+	if l.field == nil {
+		return value, errors.New("lazy dependency not processed")
+	}
+
+	// Initialize the fields in the order the processor resolved them,
+	// leaving those of nested Lazy fields to them.
+	fields := flatten(l.field)[1:]
+	sort.SliceStable(fields, func(a, b int) bool {
+		return fields[a].layer < fields[b].layer
+	})
+
+	var initialized []*Field
+	for _, field := range fields {
+		if _, ok := field.Tags.Lookup(TagInject); ok || field.lazyAncestor() != l.field || !field.IsEnabled() {
+			continue
+		}
+
+		err := initialize(ctx, field)
+		if err != nil {
+			err = fmt.Errorf("initializing lazy field %s: %w", field.Path, err)
+			return value, rollback(ctx, initialized, err)
+		}
+
+		if field.Initialized {
+			initialized = append(initialized, field)
+		}
+	}
+
+	l.initialized = initialized
+	l.done.Store(true)
+	return l.Value, nil
+}
+
+// Close the fields initialized by Get in reverse order, if any.
+func (l *Lazy[T]) Close(ctx context.Context) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if !l.done.Load() {
+		return nil
+	}
+
+	err := closeFields(ctx, l.initialized)
+	for _, field := range l.initialized {
+		field.Initialized = false
+	}
+	l.initialized = nil
+	l.done.Store(false)
+	return err
+}
+
+func (l *Lazy[T]) setup(field *Field) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.field = field
+}
+
+func (l *Lazy[T]) ready() bool {
+	return l.done.Load()
+}
+
+// Return the field as a Lazy, if it is one.
+func (f *Field) lazy() (lazy, bool) {
+	v := f.Value
+	if v.Kind() != reflect.Ptr && v.CanAddr() {
+		v = v.Addr()
+	}
+
+	l, ok := v.Interface().(lazy)
+	return l, ok
+}
+
+// Return the closest ancestor of the field that is a Lazy, or nil if there is
+// none.
+func (f *Field) lazyAncestor() *Field {
+	for ancestor := f.Parent; ancestor != nil; ancestor = ancestor.Parent {
+		if _, ok := ancestor.lazy(); ok {
+			return ancestor
+		}
+	}
+	return nil
+}
+
+// Return whether the initialization of the field is deferred by a Lazy
+// ancestor not initialized yet.
+func (f *Field) deferred() bool {
+	for ancestor := f.Parent; ancestor != nil; ancestor = ancestor.Parent {
+		if l, ok := ancestor.lazy(); ok && !l.ready() {
+			return true
+		}
+	}
+	return false
+}
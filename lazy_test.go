@@ -0,0 +1,159 @@
+package zconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type lazyDependency struct {
+	Foo   int `key:"foo"`
+	Child *closeTest
+
+	inits int
+	fail  bool
+	lock  sync.Mutex
+}
+
+func (l *lazyDependency) Init(ctx context.Context) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.inits++
+	if l.fail {
+		return errors.New("init failed")
+	}
+	return nil
+}
+
+type lazyOptional struct {
+	Opt struct {
+		Enabled bool `key:"enabled"`
+		Dep     *closeTest
+	} `key:"opt" enabled:"l.opt.enabled"`
+}
+
+func TestLazy(t *testing.T) {
+	var repository Repository
+	repository.AddParsers(ParseString)
+	repository.AddProviders(TestProvider{"test", map[string]string{"lazy.foo": "1"}})
+
+	newService := func(log *lifecycleLog) *struct {
+		Lazy Lazy[*lazyDependency] `key:"lazy"`
+	} {
+		s := new(struct {
+			Lazy Lazy[*lazyDependency] `key:"lazy"`
+		})
+		s.Lazy.Value = &lazyDependency{Child: &closeTest{name: "child", log: log}}
+		return s
+	}
+
+	t.Run("deferred", func(t *testing.T) {
+		var log lifecycleLog
+		s := newService(&log)
+
+		p := NewProcessor(repository.Hook, Initialize)
+		err := p.Process(context.Background(), s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if s.Lazy.Value.Foo != 1 {
+			t.Fatalf("lazy field not configured: %d", s.Lazy.Value.Foo)
+		}
+		if s.Lazy.Value.inits != 0 || len(log) != 0 {
+			t.Fatalf("lazy field initialized too early")
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				v, err := s.Lazy.Get(context.Background())
+				if err != nil || v != s.Lazy.Value {
+					t.Errorf("unexpected result: %v, %v", v, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if s.Lazy.Value.inits != 1 {
+			t.Fatalf("unexpected number of initializations: %d", s.Lazy.Value.inits)
+		}
+
+		err = p.Shutdown(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := lifecycleLog{"init child", "close child"}
+		if !reflect.DeepEqual(log, expected) {
+			t.Fatalf("unexpected lifecycle: wanted %v, got %v", expected, log)
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		var log lifecycleLog
+		s := newService(&log)
+		s.Lazy.Value.fail = true
+
+		err := NewProcessor(repository.Hook, Initialize).Process(context.Background(), s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		_, err = s.Lazy.Get(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "initializing lazy field $.Lazy.Value: initializing field: init failed") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := lifecycleLog{"init child", "close child"}
+		if !reflect.DeepEqual(log, expected) {
+			t.Fatalf("unexpected lifecycle: wanted %v, got %v", expected, log)
+		}
+
+		// The initialization is attempted again on the next call.
+		s.Lazy.Value.fail = false
+		_, err = s.Lazy.Get(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("disabled branch", func(t *testing.T) {
+		var log lifecycleLog
+		s := new(struct {
+			Lazy Lazy[*lazyOptional] `key:"l"`
+		})
+		s.Lazy.Value = new(lazyOptional)
+		s.Lazy.Value.Opt.Dep = &closeTest{name: "dep", log: &log}
+
+		var repository Repository
+		repository.AddParsers(ParseString)
+		repository.AddProviders(TestProvider{"test", map[string]string{"l.opt.enabled": "false"}})
+
+		err := NewProcessor(repository.Hook, Initialize).Process(context.Background(), s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		_, err = s.Lazy.Get(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(log) != 0 {
+			t.Fatalf("disabled field initialized: %v", log)
+		}
+	})
+
+	t.Run("not processed", func(t *testing.T) {
+		var l Lazy[*lazyDependency]
+		_, err := l.Get(context.Background())
+		if err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+	})
+}
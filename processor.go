@@ -2,11 +2,14 @@ package zconfig
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 	"unicode"
 
 	"github.com/hchargois/flexwriter"
@@ -29,6 +32,38 @@ type Processor struct {
 	// If UsageVal is unset, then Usage is used. If Usage is unset too, then
 	// DefaultUsageVal is used.
 	UsageVal func(value string, fields []*Field)
+
+	// Repository is the repository whose hook configures the fields. It is
+	// used by Check to validate the default values of the fields, and may
+	// be left nil if the processor isn't used for configuration.
+	Repository *Repository
+
+	// IgnoredTags lists the struct tags that Check should not report as
+	// unknown, typically the ones used by other libraries (json, yaml…)
+	IgnoredTags []string
+
+	// Concurrency is the maximum number of fields a hook is executed on at
+	// the same time. Only the fields that don't depend on each other are
+	// processed concurrently, so the hooks must be safe for concurrent use.
+	// Zero or one means the fields are processed sequentially.
+	Concurrency int
+
+	// ShutdownTimeout bounds the duration of the shutdown performed by Run
+	// once the service stops. Zero means no timeout.
+	ShutdownTimeout time.Duration
+
+	lock        sync.Mutex
+	processed   map[uintptr]processedStruct // by address of the struct
+	structs     int                         // number of structs processed
+	initialized []*Field
+	reloadLock  sync.Mutex
+}
+
+// The fields of a processed struct, numbered in processing order to tell apart
+// the fields of different structs having the same path.
+type processedStruct struct {
+	id     int
+	fields []*Field
 }
 
 func NewProcessor(hooks ...Hook) *Processor {
@@ -38,28 +73,22 @@ func NewProcessor(hooks ...Hook) *Processor {
 }
 
 func (p *Processor) Process(ctx context.Context, s interface{}) error {
-	v := reflect.ValueOf(s)
-
-	if v.Kind() != reflect.Ptr {
-		return fmt.Errorf("expected pointer to struct, %T given", s)
-	}
-
-	if v.Elem().Kind() != reflect.Struct {
-		return fmt.Errorf("expected pointer to struct, %T given", s)
-	}
+	_, err := p.process(ctx, s)
+	return err
+}
 
-	root, err := walk(v, reflect.StructField{}, nil)
+// Process the given struct and return its resolved fields.
+func (p *Processor) process(ctx context.Context, s interface{}) ([]*Field, error) {
+	_, fields, err := prepare(s)
 	if err != nil {
-		return fmt.Errorf("walking struct: %w", err)
+		return nil, err
 	}
 
-	fields, err := resolve(root)
-	if err != nil {
-		return fmt.Errorf("resolving struct: %w", err)
+	// Refuse structs where two fields would silently read the same value.
+	if errs := checkKeys(fields); len(errs) != 0 {
+		return nil, fmt.Errorf("checking keys: %w", errs[0])
 	}
 
-	mark(root, "")
-
 	if rawVal, ok, _ := Args.Retrieve("help"); ok {
 		// we know rawVal is a string since it's coming from an ArgsProvider.
 		val := rawVal.(string)
@@ -78,20 +107,235 @@ func (p *Processor) Process(ctx context.Context, s interface{}) error {
 		os.Exit(0)
 	}
 
+	// Show the effective configuration, without initializing anything.
+	if rawVal, ok, _ := Args.Retrieve("print-config"); ok {
+		format := rawVal.(string)
+		if format == "" {
+			format = FormatJSON
+		}
+
+		err := p.printConfig(ctx, os.Stdout, fields, format)
+		if err != nil {
+			return nil, fmt.Errorf("printing configuration: %w", err)
+		}
+		os.Exit(0)
+	}
+
+	// Show where the value of every key comes from, without initializing
+	// anything.
+	if _, ok, _ := Args.Retrieve("config-sources"); ok {
+		if p.Repository == nil {
+			return nil, errors.New("reporting configuration sources: no repository set on the processor")
+		}
+
+		err := p.reportSources(ctx, os.Stdout, fields)
+		if err != nil {
+			return nil, fmt.Errorf("reporting configuration sources: %w", err)
+		}
+		os.Exit(0)
+	}
+
 	for _, hook := range p.hooks {
+		err := p.execute(ctx, hook, fields)
+		if err != nil {
+			return nil, rollback(ctx, fields, err)
+		}
+	}
+
+	// Keep track of the initialized fields so they can be closed on
+	// shutdown, every initialization being matched by a close, and of the
+	// processed fields so their health can be checked and they can be
+	// reloaded. The structs with nothing to track aren't retained, and the
+	// fields of a struct processed again replace the previous ones.
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var tracked bool
+	for _, field := range fields {
+		if field.Initialized {
+			p.initialized = append(p.initialized, field)
+		}
+		if field.Initialized || field.IsReloadable() || field.Value.Type().Implements(typeHealthChecker) {
+			tracked = true
+		}
+	}
+
+	key := reflect.ValueOf(s).Pointer()
+	if !tracked {
+		delete(p.processed, key)
+		return fields, nil
+	}
+
+	if p.processed == nil {
+		p.processed = make(map[uintptr]processedStruct)
+	}
+
+	processed, ok := p.processed[key]
+	if !ok {
+		p.structs++
+		processed.id = p.structs
+	}
+	processed.fields = fields
+	p.processed[key] = processed
+
+	return fields, nil
+}
+
+func (p *Processor) AddHooks(hooks ...Hook) {
+	p.hooks = append(p.hooks, hooks...)
+}
+
+// Execute a hook on the resolved fields, either sequentially or layer by
+// layer depending on the concurrency of the processor.
+func (p *Processor) execute(ctx context.Context, hook Hook, fields []*Field) error {
+	if p.Concurrency <= 1 {
 		for _, field := range fields {
 			err := hook(ctx, field)
 			if err != nil {
 				return fmt.Errorf("executing hook on field %s: %w", field.Path, err)
 			}
 		}
+		return nil
 	}
 
+	for _, layer := range layers(fields) {
+		err := p.executeLayer(ctx, hook, layer)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (p *Processor) AddHooks(hooks ...Hook) {
-	p.hooks = append(p.hooks, hooks...)
+// Execute a hook concurrently on a layer of independent fields. The first
+// failure cancels the context given to the fields still in flight, and the
+// fields not started yet are skipped. The context of the successful fields is
+// left alone, as they may keep using it once processed. In order to stay
+// deterministic, the returned error is the one of the first failing field of
+// the layer, ignoring the cancellations caused by another failure. If the
+// context is done, the fields not started yet are skipped too, and the error
+// names the first of them.
+func (p *Processor) executeLayer(ctx context.Context, hook Hook, layer []*Field) error {
+	var (
+		errs    = make([]error, len(layer))
+		workers = make(chan struct{}, p.Concurrency)
+		wg      sync.WaitGroup
+
+		lock    sync.Mutex
+		failed  bool
+		running = make(map[int]context.CancelFunc)
+		skipped error
+	)
+	for i, field := range layer {
+		workers <- struct{}{}
+
+		lock.Lock()
+		if failed || ctx.Err() != nil {
+			// Without failure, the context is the reason to stop.
+			if !failed {
+				skipped = fmt.Errorf("executing hook on field %s: %w", field.Path, ctx.Err())
+			}
+			lock.Unlock()
+			<-workers
+			break
+		}
+		hookCtx, cancel := context.WithCancel(ctx)
+		running[i] = cancel
+		lock.Unlock()
+
+		wg.Add(1)
+		go func(i int, field *Field) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+
+			err := hook(hookCtx, field)
+
+			lock.Lock()
+			defer lock.Unlock()
+			delete(running, i)
+			if err == nil {
+				return
+			}
+
+			errs[i] = fmt.Errorf("executing hook on field %s: %w", field.Path, err)
+			cancel()
+			if !failed {
+				failed = true
+				for _, cancel := range running {
+					cancel()
+				}
+			}
+		}(i, field)
+	}
+	wg.Wait()
+
+	var first error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, context.Canceled) {
+			return err
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	if first == nil {
+		return skipped
+	}
+	return first
+}
+
+// Close the fields already initialized when processing failed, and attach
+// the closing errors, if any, to the original error.
+func rollback(ctx context.Context, fields []*Field, err error) error {
+	var initialized []*Field
+	for _, field := range fields {
+		if field.Initialized {
+			initialized = append(initialized, field)
+		}
+	}
+
+	// The processing context may well be the reason of the failure, so
+	// don't let its cancellation prevent the fields from being closed.
+	closeErr := closeFields(context.WithoutCancel(ctx), initialized)
+	if closeErr != nil {
+		return errors.Join(err, closeErr)
+	}
+	return err
+}
+
+// Walk the given struct, resolve its dependencies and compute the
+// configuration keys of its fields. Return the root field and the resolved
+// list of fields.
+func prepare(s interface{}) (root *Field, fields []*Field, err error) {
+	v := reflect.ValueOf(s)
+
+	if v.Kind() != reflect.Ptr {
+		return nil, nil, fmt.Errorf("expected pointer to struct, %T given", s)
+	}
+
+	if v.Elem().Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("expected pointer to struct, %T given", s)
+	}
+
+	root, err = walk(v, reflect.StructField{}, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("walking struct: %w", err)
+	}
+
+	// The configuration keys are needed to resolve the enabled tags.
+	mark(root, "")
+
+	fields, err = resolve(root)
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolving struct: %w", err)
+	}
+
+	return root, fields, nil
 }
 
 func walk(v reflect.Value, s reflect.StructField, p *Field) (field *Field, err error) {
@@ -105,7 +349,10 @@ func walk(v reflect.Value, s reflect.StructField, p *Field) (field *Field, err e
 		field.Anonymous = true
 	} else {
 		field.Path = fmt.Sprintf("%s.%s", p.Path, s.Name)
-		field.Anonymous = s.Anonymous
+		// The value wrapped by a Lazy is configured as if it were
+		// declared in place of it.
+		_, wrapped := p.lazy()
+		field.Anonymous = s.Anonymous || wrapped
 		field.Tags = s.Tag
 
 		key, ok := field.Tags.Lookup(TagKey)
@@ -197,6 +444,9 @@ func resolve(root *Field) (fields []*Field, err error) {
 		paths        = make(map[string]*Field)
 		sources      = make(map[string]*Field)
 		targets      = make(map[*Field]string)
+		afters       = make(map[*Field][]string)
+		enableds     = make(map[*Field]string)
+		keys         = make(map[string]*Field)
 		dependencies = make(dependencies)
 	)
 	for len(stack) != 0 {
@@ -220,6 +470,18 @@ func resolve(root *Field) (fields []*Field, err error) {
 			targets[e] = key
 		}
 
+		if names := e.After(); names != nil {
+			afters[e] = names
+		}
+
+		if key, ok := e.Tags.Lookup(TagEnabled); ok {
+			enableds[e] = key
+		}
+
+		if e.Configurable {
+			keys[e.ConfigurationKey] = e
+		}
+
 		// Safeguard against children and/or dependency modification
 		// later in the process by copying the slice right now.]
 		dependencies.add(e, e.Children...)
@@ -241,21 +503,64 @@ func resolve(root *Field) (fields []*Field, err error) {
 		dependencies.add(target, source)
 	}
 
+	// Make the fields with an after tag, and their whole subtree, depend on
+	// the siblings they name.
+	for field, names := range afters {
+		for _, name := range names {
+			sibling := field.sibling(name)
+			if sibling == nil {
+				return nil, fmt.Errorf("unknown sibling %s in %s tag of field %s", name, TagAfter, field.Path)
+			}
+
+			for _, f := range flatten(field) {
+				dependencies.add(f, sibling)
+			}
+		}
+	}
+
+	// Make the branches of the fields with an enabled tag depend on the
+	// boolean field holding the given configuration key, so it is
+	// configured first.
+	for field, key := range enableds {
+		enabler, ok := keys[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown key %s in %s tag of field %s", key, TagEnabled, field.Path)
+		}
+
+		if enabler.Value.Kind() != reflect.Bool && (enabler.Value.Kind() != reflect.Ptr || enabler.Value.Type().Elem().Kind() != reflect.Bool) {
+			return nil, fmt.Errorf("key %s in %s tag of field %s is not a boolean", key, TagEnabled, field.Path)
+		}
+		field.enabler = enabler
+
+		for _, f := range flatten(field) {
+			if f != enabler {
+				dependencies.add(f, enabler)
+			}
+		}
+	}
+
+	// Index the fields by declaration order, used to sort the fields of a
+	// layer so the resolved order is deterministic.
+	var order = make(map[*Field]int)
+	for i, f := range flatten(root) {
+		order[f] = i
+	}
+
 	// Resolve the dependency graph by finding fields that have no
 	// dependency and removing them from the graph and the dependencies of
 	// the other fields. Iterate until the graph is empty, in which case we
-	// obtain a resolved set of fields.
-	for len(dependencies) != 0 {
+	// obtain a resolved set of fields. The fields resolved in the same
+	// iteration form a layer of fields independent of each other.
+	for layer := 0; len(dependencies) != 0; layer++ {
 		var resolved = make([]*Field, 0)
 		for path, deps := range dependencies {
-			if len(deps) != 0 {
-				continue
+			if len(deps) == 0 {
+				resolved = append(resolved, paths[path])
 			}
-
-			resolved = append(resolved, paths[path])
-			// Remove the field from the other fields dependencies list
-			dependencies.remove(path)
 		}
+		sort.Slice(resolved, func(a, b int) bool {
+			return order[resolved[a]] < order[resolved[b]]
+		})
 
 		// If there was no resolved field, this means that there is a
 		// circular dependency because all remaining fields are
@@ -265,6 +570,10 @@ func resolve(root *Field) (fields []*Field, err error) {
 		}
 
 		for _, res := range resolved {
+			// Remove the field from the other fields dependencies list
+			dependencies.remove(res.Path)
+			res.layer = layer
+
 			// Do not add injection targets to resolved fields because their sources will also be added
 			if _, ok := res.Tags.Lookup(TagInject); !ok {
 				fields = append(fields, res)
@@ -275,11 +584,22 @@ func resolve(root *Field) (fields []*Field, err error) {
 	return fields, nil
 }
 
+// Split a list of resolved fields into their layers.
+func layers(fields []*Field) (layers [][]*Field) {
+	for i, field := range fields {
+		if i == 0 || field.layer != fields[i-1].layer {
+			layers = append(layers, nil)
+		}
+		layers[len(layers)-1] = append(layers[len(layers)-1], field)
+	}
+	return layers
+}
+
 func newCycleError(dependencies dependencies) error {
 	var paths [][]string
 
-	for path, deps := range dependencies {
-		for depPath := range deps {
+	for _, path := range sortedKeys(dependencies) {
+		for _, depPath := range sortedKeys(dependencies[path]) {
 			paths = append(paths, []string{path, depPath})
 		}
 	}
@@ -287,18 +607,26 @@ func newCycleError(dependencies dependencies) error {
 	for {
 		var next [][]string
 		for _, path := range paths {
-			for fieldPath := range dependencies[path[len(path)-1]] {
+			for _, fieldPath := range sortedKeys(dependencies[path[len(path)-1]]) {
 				if fieldPath == path[0] {
 					return fmt.Errorf("cycle detected: %s", strings.Join(path, " -> "))
 				}
 
-				next = append(next, append(path, fieldPath))
+				next = append(next, append(path[:len(path):len(path)], fieldPath))
 			}
 		}
 		paths = next
 	}
 }
 
+func sortedKeys[V any](m map[string]V) (keys []string) {
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Mark the configurable fields and compute their configuration key. Return
 // true if the current field or one of its children is configurable (used for
 // recursion.)
@@ -348,14 +676,77 @@ func mark(f *Field, key string) bool {
 	return true
 }
 
+// Return the description of a field as displayed in the help message or in
+// a sample configuration, completed by the values it accepts, the key
+// enabling it and its deprecation, if any.
+func describe(f *Field) string {
+	desc, _ := f.Tags.Lookup(TagDescription)
+	if values := f.Enum(); values != nil {
+		desc = strings.TrimSpace(desc + " (one of: " + strings.Join(values, ", ") + ")")
+	}
+	if condition := f.Condition(); condition != "" {
+		desc = strings.TrimSpace(desc + " (if " + condition + ")")
+	}
+	if message, ok := f.Tags.Lookup(TagDeprecated); ok {
+		if message != "" {
+			message = ": " + message
+		}
+		desc = strings.TrimSpace(desc + " (deprecated" + message + ")")
+	}
+	return desc
+}
+
 // DefaultUsageVal prints a usage message that lists the fields with their keys
 // in CLI form (e.g. --foo) and environment variable form (e.g. FOO), as well as
-// the fields descriptions and default values (if any).
+// the fields descriptions, allowed values, conditions and default values (if
+// any). The default values of secret fields are redacted.
 //
 // If called with the "cli" value, only the CLI form is printed, and if called
 // with the "env" value, only the environment variable form is printed. Any
-// other value (including an empty value) prints both forms.
+// other value (including an empty value) prints both forms. The values
+// starting with "sample-", like "sample-yaml", print a sample configuration
+// file instead, see GenerateSample, the "markdown", "man" and "asciidoc"
+// values print the documentation of the keys, see GenerateDocs, and the
+// values starting with "completion-", like "completion-bash", print a shell
+// completion script, see GenerateCompletion. Finally, the "json" value prints
+// a JSON document describing the keys, for the tools needing them. If the
+// output can't be generated, for example for an unknown sample format or
+// shell, the error is printed and the program exits with status 2.
 func DefaultUsageVal(val string, fields []*Field) {
+	if format, ok := strings.CutPrefix(val, "sample-"); ok {
+		raw, err := GenerateSample(fields, format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "generating sample: %s\n", err)
+			os.Exit(2)
+		}
+		_, _ = os.Stdout.Write(raw)
+		return
+	}
+
+	if shell, ok := strings.CutPrefix(val, "completion-"); ok {
+		raw, err := GenerateCompletion(fields, shell)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "generating completion: %s\n", err)
+			os.Exit(2)
+		}
+		_, _ = os.Stdout.Write(raw)
+		return
+	}
+
+	switch val {
+	case FormatJSON:
+		err := writeJSONUsage(os.Stdout, fields)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "writing usage: %s\n", err)
+			os.Exit(2)
+		}
+		return
+	case FormatMarkdown, FormatMan, FormatAsciiDoc:
+		raw, _ := GenerateDocs(fields, val)
+		_, _ = os.Stdout.Write(raw)
+		return
+	}
+
 	var keys []string
 	var options = make(map[string]*Field)
 	for _, f := range fields {
@@ -387,11 +778,12 @@ func DefaultUsageVal(val string, fields []*Field) {
 
 	for _, key := range keys {
 		field := options[key]
-		desc, _ := field.Tags.Lookup(TagDescription)
-
-		row := []any{"--" + key, Env.FormatKey(key), desc}
+		row := []any{"--" + key, Env.FormatKey(key), describe(field)}
 
 		def, ok := field.Tags.Lookup(TagDefault)
+		if ok && field.IsSecret() {
+			def = Redacted
+		}
 		if ok {
 			optional.WriteRow(append(row, "("+def+")")...)
 		} else {
@@ -3,8 +3,12 @@ package zconfig
 import (
 	"context"
 	"errors"
+	"io"
+	"os"
+	"os/exec"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -145,6 +149,99 @@ func TestResolve(t *testing.T) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
+
+	t.Run("after", func(t *testing.T) {
+		var v = reflect.ValueOf(new(struct {
+			DB      *SimpleDependency `after:"Metrics, Tracer"`
+			Metrics *SimpleDependency
+			Tracer  *SimpleDependency
+		}))
+		root, err := walk(v, reflect.StructField{}, nil)
+		if err != nil {
+			t.Fatalf("walking service: %s", err)
+		}
+
+		fields, err := resolve(root)
+		if err != nil {
+			t.Fatalf("resolving graph: %s", err)
+		}
+
+		displayResolvedGraph(t, fields)
+
+		var index = make(map[string]int)
+		for i, field := range fields {
+			index[field.Path] = i
+		}
+
+		for _, path := range []string{"$.DB", "$.DB.Foo"} {
+			for _, dep := range []string{"$.Metrics", "$.Tracer"} {
+				if index[path] < index[dep] {
+					t.Errorf("field %s resolved before %s", path, dep)
+				}
+			}
+		}
+	})
+
+	t.Run("deterministic order", func(t *testing.T) {
+		type deps struct {
+			C *SimpleDependency
+			A *SimpleDependency `after:"C"`
+			B *SimpleDependency
+		}
+
+		expected := []string{"$.C.Foo", "$.B.Foo", "$.C", "$.B", "$.A.Foo", "$.A", "$"}
+		for i := 0; i < 20; i++ {
+			root, err := walk(reflect.ValueOf(new(deps)), reflect.StructField{}, nil)
+			if err != nil {
+				t.Fatalf("walking service: %s", err)
+			}
+
+			fields, err := resolve(root)
+			if err != nil {
+				t.Fatalf("resolving graph: %s", err)
+			}
+
+			var actual []string
+			for _, f := range fields {
+				actual = append(actual, f.Path)
+			}
+
+			if !reflect.DeepEqual(actual, expected) {
+				t.Fatalf("unexpected order: wanted %v, got %v", expected, actual)
+			}
+		}
+	})
+
+	for name, tCase := range map[string]struct {
+		s        interface{}
+		expected string
+	}{
+		"after cycle": {
+			s: new(struct {
+				A *SimpleDependency `after:"B"`
+				B *SimpleDependency `after:"A"`
+			}),
+			expected: "cycle detected",
+		},
+		"after unknown": {
+			s: new(struct {
+				A *SimpleDependency `after:"C"`
+			}),
+			expected: "unknown sibling C in after tag of field $.A",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			root, err := walk(reflect.ValueOf(tCase.s), reflect.StructField{}, nil)
+			if err != nil {
+				t.Fatalf("walking service: %s", err)
+			}
+
+			_, err = resolve(root)
+			if err == nil || !strings.Contains(err.Error(), tCase.expected) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
 }
 
 func displayResolvedGraph(t *testing.T, fields []*Field) {
@@ -188,6 +285,10 @@ func TestNewCycleError(t *testing.T) {
 	}
 
 	t.Log(err)
+
+	if err.Error() != "cycle detected: B -> D -> C -> E" {
+		t.Fatalf("unexpected error: %v", err)
+	}
 }
 
 func TestDependencies(t *testing.T) {
@@ -287,3 +388,256 @@ func TestProcessorHooks(t *testing.T) {
 		}
 	})
 }
+
+func TestProcessorKeys(t *testing.T) {
+	for name, tCase := range map[string]struct {
+		s        interface{}
+		expected []string
+	}{
+		"duplicate key": {
+			s: new(struct {
+				F
+				DuplicateF
+			}),
+			expected: []string{"duplicate key b for fields", "$.F.B", "$.DuplicateF.B"},
+		},
+		"duplicate environment variable": {
+			s: new(struct {
+				Dot  int `key:"a.b"`
+				Dash int `key:"a-b"`
+			}),
+			expected: []string{"share the same environment variable A_B", "$.Dot", "$.Dash"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := NewProcessor().Process(context.Background(), tCase.s)
+			if err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+
+			for _, expected := range tCase.expected {
+				if !strings.Contains(err.Error(), expected) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func captureStdout(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("creating pipe: %s", err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	var out = make(chan string)
+	go func() {
+		raw, _ := io.ReadAll(r)
+		out <- string(raw)
+	}()
+
+	fn()
+	_ = w.Close()
+	return <-out
+}
+
+func TestDefaultUsageVal(t *testing.T) {
+	_, fields, err := prepare(new(struct {
+		Addr     string `key:"addr" description:"address to bind" default:":80"`
+		Password string `key:"password" description:"database password" default:"hunter2" secret:"true"`
+		Required string `key:"required"`
+		Format   string `key:"format" description:"log format" enum:"json,text" default:"json"`
+		Tracing  struct {
+			Enabled  bool   `key:"enabled" default:"false"`
+			Endpoint string `key:"endpoint" description:"tracing endpoint"`
+		} `key:"tracing" enabled:"tracing.enabled"`
+	}))
+	if err != nil {
+		t.Fatalf("preparing struct: %s", err)
+	}
+
+	out := captureStdout(t, func() { DefaultUsageVal("", fields) })
+	t.Log(out)
+
+	for _, expected := range []string{"--addr", "ADDR", "(:80)", "--password", "(***)", "--required", "log format (one of: json, text)", "tracing endpoint (if tracing.enabled)"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in usage", expected)
+		}
+	}
+
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("secret leaked in usage")
+	}
+}
+
+func TestDefaultUsageValExit(t *testing.T) {
+	// Executed by the child process.
+	if val := os.Getenv("ZCONFIG_TEST_USAGE"); val != "" {
+		DefaultUsageVal(val, nil)
+		return
+	}
+
+	for _, val := range []string{"sample-xml", "completion-tcsh"} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestDefaultUsageValExit$")
+		cmd.Env = append(os.Environ(), "ZCONFIG_TEST_USAGE="+val)
+		err := cmd.Run()
+
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 2 {
+			t.Errorf("unexpected result for %s: %v", val, err)
+		}
+	}
+}
+
+type concurrentInitTest struct {
+	running, max *int32
+	fail         bool
+}
+
+func (c *concurrentInitTest) Init(ctx context.Context) error {
+	n := atomic.AddInt32(c.running, 1)
+	defer atomic.AddInt32(c.running, -1)
+	for {
+		max := atomic.LoadInt32(c.max)
+		if n <= max || atomic.CompareAndSwapInt32(c.max, max, n) {
+			break
+		}
+	}
+
+	if c.fail {
+		return errors.New("init failed")
+	}
+
+	select {
+	case <-time.After(20 * time.Millisecond):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestProcessorConcurrency(t *testing.T) {
+	newService := func(fail bool) (*[6]*concurrentInitTest, *int32) {
+		var running, max int32
+		var s [6]*concurrentInitTest
+		for i := range s {
+			s[i] = &concurrentInitTest{running: &running, max: &max}
+		}
+		s[3].fail = fail
+		return &s, &max
+	}
+
+	t.Run("limit", func(t *testing.T) {
+		s, max := newService(false)
+		p := NewProcessor(Initialize)
+		p.Concurrency = 3
+
+		err := p.Process(context.Background(), &struct {
+			A, B, C, D, E, F *concurrentInitTest
+		}{s[0], s[1], s[2], s[3], s[4], s[5]})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if *max != 3 {
+			t.Fatalf("unexpected concurrency: wanted 3, got %d", *max)
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		s, _ := newService(true)
+		p := NewProcessor(Initialize)
+		p.Concurrency = 6
+
+		err := p.Process(context.Background(), &struct {
+			A, B, C, D, E, F *concurrentInitTest
+		}{s[0], s[1], s[2], s[3], s[4], s[5]})
+		if err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+
+		if !strings.HasPrefix(err.Error(), "executing hook on field $.D: initializing field: init failed") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("context", func(t *testing.T) {
+		s := &struct {
+			A, B *contextInitTest
+		}{new(contextInitTest), new(contextInitTest)}
+
+		for _, concurrency := range []int{1, 2} {
+			p := NewProcessor(Initialize)
+			p.Concurrency = concurrency
+
+			err := p.Process(context.Background(), s)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if s.A.ctx.Err() != nil || s.B.ctx.Err() != nil {
+				t.Fatalf("init context cancelled after processing with a concurrency of %d", concurrency)
+			}
+		}
+	})
+}
+
+func TestProcessorConcurrencyCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := &struct {
+		A, B *contextInitTest
+	}{new(contextInitTest), new(contextInitTest)}
+
+	p := NewProcessor(Initialize)
+	p.Concurrency = 4
+
+	err := p.Process(ctx, s)
+	if !errors.Is(err, context.Canceled) || !strings.HasPrefix(err.Error(), "executing hook on field $.A: ") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.A.ctx != nil || s.B.ctx != nil {
+		t.Fatal("field initialized with a canceled context")
+	}
+}
+
+type contextInitTest struct {
+	ctx context.Context
+}
+
+func (c *contextInitTest) Init(ctx context.Context) error {
+	c.ctx = ctx
+	return nil
+}
+
+func TestLayers(t *testing.T) {
+	root, err := walk(reflect.ValueOf(new(Service)), reflect.StructField{}, nil)
+	if err != nil {
+		t.Fatalf("walking service: %s", err)
+	}
+
+	fields, err := resolve(root)
+	if err != nil {
+		t.Fatalf("resolving graph: %s", err)
+	}
+
+	var depths = make(map[string]int)
+	for i, layer := range layers(fields) {
+		for _, f := range layer {
+			depths[f.Path] = i
+		}
+	}
+
+	for _, f := range fields {
+		for _, c := range f.Children {
+			if _, ok := depths[c.Path]; ok && depths[c.Path] >= depths[f.Path] {
+				t.Errorf("field %s in layer %d before its child %s in layer %d", f.Path, depths[f.Path], c.Path, depths[c.Path])
+			}
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package zconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Reloadable is the interface implemented by the fields tagged reload that
+// need to act upon a change of their value, or of the value of one of their
+// children. OnReload is called with a copy of the previous value of the field
+// and its new value, once every change is set.
+type Reloadable interface {
+	OnReload(ctx context.Context, old, new interface{}) error
+}
+
+// A Change is the new value of a configurable field, detected by Reload.
+type Change struct {
+	Field    *Field
+	Old      interface{}
+	New      interface{}
+	Provider string
+
+	// Applied is true if the field is reloadable and its value was
+	// changed, false if a restart is required for the change to be taken
+	// into account.
+	Applied bool
+}
+
+// Reload loads again the values of the configurable fields of a service
+// processed by the processor, using its repository, and returns the changes.
+// The new values are applied only to the reloadable fields (see the reload
+// tag), and the tagged fields are then notified via the Reloadable interface
+// in resolution order; the other changes require a restart. No change is
+// applied if any value can't be loaded.
+func (p *Processor) Reload(ctx context.Context, s interface{}) ([]Change, error) {
+	if p.Repository == nil {
+		return nil, errors.New("no repository to reload from")
+	}
+
+	fields, err := p.fieldsOf(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p.reloadLock.Lock()
+	defer p.reloadLock.Unlock()
+
+	var changes []Change
+	for _, field := range fields {
+		if !field.Configurable {
+			continue
+		}
+
+		var (
+			current = pointer(field.Value)
+			res     = field.newValue()
+		)
+
+		provider, err := p.Repository.load(field, res)
+		if err != nil {
+			return nil, fmt.Errorf("reloading field %s: %w", field.Path, err)
+		}
+
+		if provider == "" || reflect.DeepEqual(valueOf(current.Interface()), valueOf(res)) {
+			continue
+		}
+
+		changes = append(changes, Change{
+			Field:    field,
+			Old:      valueOf(current.Interface()),
+			New:      valueOf(res),
+			Provider: provider,
+			Applied:  field.IsReloadable(),
+		})
+	}
+
+	// Keep the previous value of the reloadable fields to notify, which are
+	// the tagged fields whose subtree changed.
+	var (
+		notified = make(map[*Field]interface{})
+		order    []*Field
+	)
+	for _, change := range changes {
+		if !change.Applied {
+			continue
+		}
+
+		for f := change.Field; f != nil; f = f.Parent {
+			if reload, _ := strconv.ParseBool(f.Tags.Get(TagReload)); !reload {
+				continue
+			}
+			if _, ok := notified[f]; ok {
+				continue
+			}
+			if _, ok := pointer(f.Value).Interface().(Reloadable); !ok {
+				continue
+			}
+			notified[f] = pointer(f.Value).Elem().Interface()
+			order = append(order, f)
+		}
+	}
+
+	for _, change := range changes {
+		if !change.Applied {
+			continue
+		}
+
+		target := pointer(change.Field.Value)
+		if d, ok := target.Interface().(dynamic); ok {
+			d.store(change.New)
+		} else {
+			target.Elem().Set(reflect.ValueOf(change.New))
+		}
+		change.Field.Provider = change.Provider
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return order[i].layer < order[j].layer
+	})
+
+	var errs []error
+	for _, f := range order {
+		target := pointer(f.Value)
+		err := target.Interface().(Reloadable).OnReload(ctx, notified[f], target.Elem().Interface())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reloading field %s: %w", f.Path, err))
+		}
+	}
+
+	return changes, errors.Join(errs...)
+}
+
+// Watch reloads the given service every time a provider of the repository
+// signals a change, until the context is done. The errors and the changes
+// requiring a restart are logged through the DefaultLogger.
+func (p *Processor) Watch(ctx context.Context, s interface{}) error {
+	if p.Repository == nil {
+		return errors.New("no repository to watch")
+	}
+
+	_, err := p.fieldsOf(s)
+	if err != nil {
+		return err
+	}
+
+	events := p.Repository.Watch(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-events:
+		}
+
+		changes, err := p.Reload(ctx, s)
+		if err != nil {
+			DefaultLogger.Printf("reloading configuration: %s", err)
+		}
+
+		for _, change := range changes {
+			if !change.Applied {
+				DefaultLogger.Printf("configuration key %s changed, restart required", change.Field.ConfigurationKey)
+			}
+		}
+	}
+}
+
+// Return the processed fields of the given service.
+func (p *Processor) fieldsOf(s interface{}) (fields []*Field, err error) {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("expected pointer to struct, %T given", s)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	processed, ok := p.processed[v.Pointer()]
+	if !ok {
+		return nil, fmt.Errorf("%T not processed", s)
+	}
+	return processed.fields, nil
+}
+
+// Return a pointer to the given value, which must be a pointer or
+// addressable.
+func pointer(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Ptr {
+		return v
+	}
+	return v.Addr()
+}
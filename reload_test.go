@@ -0,0 +1,198 @@
+package zconfig
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type reloadTest struct {
+	Level string `key:"level"`
+
+	reloads []string
+	notify  chan struct{}
+}
+
+func (r *reloadTest) OnReload(ctx context.Context, old, new interface{}) error {
+	r.reloads = append(r.reloads, old.(reloadTest).Level+"->"+new.(reloadTest).Level)
+	if r.notify != nil {
+		r.notify <- struct{}{}
+	}
+	return nil
+}
+
+// A watchedProvider is a TestProvider signaling every change of its values.
+type watchedProvider struct {
+	TestProvider
+	events chan struct{}
+}
+
+func (p *watchedProvider) Watch(ctx context.Context) <-chan struct{} {
+	return p.events
+}
+
+func TestProcessorReload(t *testing.T) {
+	var provider = TestProvider{name: "test", values: map[string]string{
+		"level":      "info",
+		"addr":       "localhost:80",
+		"port":       "80",
+		"feature.on": "false",
+	}}
+
+	var repository Repository
+	repository.AddProviders(provider)
+	repository.AddParsers(ParseString)
+
+	p := NewProcessor(repository.Hook)
+	p.Repository = &repository
+
+	var s struct {
+		Level   *reloadTest `key:"logger" reload:"true"`
+		Addr    string      `key:"addr"`
+		Port    int         `key:"port" reload:"true"`
+		Feature struct {
+			On bool `key:"on"`
+		} `key:"feature" reload:"true"`
+	}
+	s.Level = &reloadTest{}
+
+	_, err := p.Reload(context.Background(), &s)
+	if err == nil || !strings.Contains(err.Error(), "not processed") {
+		t.Fatalf("unexpected error for unprocessed struct: %v", err)
+	}
+
+	provider.values["logger.level"] = "info"
+	err = p.Process(context.Background(), &s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("no change", func(t *testing.T) {
+		changes, err := p.Reload(context.Background(), &s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(changes) != 0 {
+			t.Fatalf("unexpected changes: %+v", changes)
+		}
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		provider.values["logger.level"] = "debug"
+		provider.values["addr"] = "localhost:81"
+		provider.values["port"] = "81"
+		provider.values["feature.on"] = "true"
+		defer func() { provider.values["addr"] = "localhost:80" }()
+
+		changes, err := p.Reload(context.Background(), &s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		applied := make(map[string]bool)
+		for _, change := range changes {
+			applied[change.Field.ConfigurationKey] = change.Applied
+		}
+		expected := map[string]bool{"logger.level": true, "addr": false, "port": true, "feature.on": true}
+		if len(applied) != len(expected) {
+			t.Fatalf("unexpected changes: %v", applied)
+		}
+		for key, value := range expected {
+			if applied[key] != value {
+				t.Fatalf("unexpected changes: %v", applied)
+			}
+		}
+
+		if s.Level.Level != "debug" || s.Port != 81 || !s.Feature.On {
+			t.Fatalf("reloadable fields not updated: %+v", s)
+		}
+		if s.Addr != "localhost:80" {
+			t.Fatalf("non reloadable field updated: %s", s.Addr)
+		}
+		if len(s.Level.reloads) != 1 || s.Level.reloads[0] != "info->debug" {
+			t.Fatalf("unexpected reloads: %v", s.Level.reloads)
+		}
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		provider.values["logger.level"] = "warn"
+		provider.values["port"] = "eighty"
+		defer func() { provider.values["port"] = "81" }()
+
+		_, err := p.Reload(context.Background(), &s)
+		if err == nil || !strings.Contains(err.Error(), "reloading field $.Port") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if s.Level.Level != "debug" {
+			t.Fatalf("change applied despite the error: %s", s.Level.Level)
+		}
+	})
+}
+
+func TestProcessorWatch(t *testing.T) {
+	var logger testLogger
+	defaultLogger := DefaultLogger
+	DefaultLogger = &logger
+	defer func() { DefaultLogger = defaultLogger }()
+
+	var provider = &watchedProvider{
+		TestProvider: TestProvider{name: "test", values: map[string]string{
+			"logger.level": "info",
+			"addr":         "localhost:80",
+		}},
+		events: make(chan struct{}),
+	}
+
+	var repository Repository
+	repository.AddProviders(provider)
+	repository.AddParsers(ParseString)
+
+	p := NewProcessor(repository.Hook)
+	p.Repository = &repository
+
+	var s struct {
+		Level *reloadTest `key:"logger" reload:"true"`
+		Addr  string      `key:"addr"`
+	}
+	s.Level = &reloadTest{notify: make(chan struct{})}
+
+	err := p.Process(context.Background(), &s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- p.Watch(ctx, &s)
+	}()
+
+	provider.values["addr"] = "localhost:81"
+	provider.values["logger.level"] = "debug"
+	provider.events <- struct{}{}
+
+	select {
+	case <-s.Level.notify:
+	case <-time.After(time.Second):
+		t.Fatal("field not reloaded")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("watch didn't return")
+	}
+
+	if s.Level.Level != "debug" {
+		t.Fatalf("reloadable field not updated: %s", s.Level.Level)
+	}
+	if len(logger) == 0 || logger[0] != "configuration key addr changed, restart required" {
+		t.Fatalf("unexpected logs: %v", logger)
+	}
+}
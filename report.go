@@ -0,0 +1,171 @@
+package zconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hchargois/flexwriter"
+)
+
+// A Retrieved value of a key, and the provider it comes from.
+type Retrieved struct {
+	Provider string
+	Value    interface{}
+}
+
+// RetrieveAll retrieves a key from every provider, by priority order, rather
+// than stopping at the first one that has a value.
+func (r *Repository) RetrieveAll(key string) (values []Retrieved, err error) {
+	for _, p := range r.providers {
+		value, found, err := p.Retrieve(key)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving key %s from provider %s: %w", key, p.Name(), err)
+		}
+		if found {
+			values = append(values, Retrieved{Provider: p.Name(), Value: value})
+		}
+	}
+	return values, nil
+}
+
+// A KeyReport tells where the value of a configuration key comes from.
+type KeyReport struct {
+	Key string
+
+	// Value of the field, redacted if it is a secret.
+	Value string
+
+	// Provider that supplied the value, empty if the field wasn't
+	// configured.
+	Provider string
+
+	// Shadowed lists the values of the key found in the other providers,
+	// ignored because of their priority or of the sources tag of the field.
+	Shadowed []Retrieved
+
+	// Error configuring the field, if any, in which case its value is
+	// empty.
+	Error error
+}
+
+// Report the value of every configurable field of the given processed
+// fields, the provider that supplied it and the other providers having a
+// value for the same key, sorted by key.
+func (r *Repository) Report(fields []*Field) (reports []KeyReport, err error) {
+	for _, f := range fields {
+		if !f.Configurable {
+			continue
+		}
+
+		values, err := r.RetrieveAll(f.ConfigurationKey)
+		if err != nil {
+			return nil, err
+		}
+
+		report := KeyReport{
+			Key:      f.ConfigurationKey,
+			Value:    flatValue(dumpValue(reflect.ValueOf(valueOf(pointer(f.Value).Interface())))),
+			Provider: f.Provider,
+		}
+		for _, v := range values {
+			if v.Provider == f.Provider {
+				continue
+			}
+			if f.IsSecret() {
+				v.Value = Redacted
+			}
+			report.Shadowed = append(report.Shadowed, v)
+		}
+		if f.IsSecret() {
+			report.Value = Redacted
+		}
+
+		reports = append(reports, report)
+	}
+
+	sort.Slice(reports, func(a, b int) bool {
+		return reports[a].Key < reports[b].Key
+	})
+	return reports, nil
+}
+
+// WriteReport writes the reports in columns: the key, its value, the
+// provider that supplied it, and the shadowed values.
+func WriteReport(w io.Writer, reports []KeyReport) error {
+	writer := flexwriter.New()
+	writer.SetOutput(w)
+	writer.SetColumns(
+		flexwriter.Rigid{},      // key
+		flexwriter.Shrinkable{}, // value
+		flexwriter.Rigid{},      // provider
+		flexwriter.Shrinkable{}, // shadowed values
+	)
+
+	writer.WriteRow("KEY", "VALUE", "PROVIDER", "ALSO SET IN")
+	for _, report := range reports {
+		value, provider := report.Value, report.Provider
+		if report.Error != nil {
+			value = "error: " + report.Error.Error()
+		}
+		if provider == "" {
+			provider = "-"
+		}
+
+		var shadowed []string
+		for _, v := range report.Shadowed {
+			shadowed = append(shadowed, fmt.Sprintf("%s=%v", v.Provider, v.Value))
+		}
+
+		writer.WriteRow(report.Key, value, provider, strings.Join(shadowed, " "))
+	}
+
+	return writer.Flush()
+}
+
+// Configure the fields using only the hook of the repository, then write the
+// provenance of their values to the given writer.
+func (p *Processor) reportSources(ctx context.Context, w io.Writer, fields []*Field) error {
+	reports, err := p.sourceReports(ctx, fields)
+	if err != nil {
+		return err
+	}
+
+	return WriteReport(w, reports)
+}
+
+// Configure the fields using only the hook of the repository and report the
+// provenance of their values. The fields that can't be configured are
+// reported with their error, as they are usually the reason for looking at
+// the report.
+func (p *Processor) sourceReports(ctx context.Context, fields []*Field) ([]KeyReport, error) {
+	var errs = make(map[string]error)
+	for _, field := range fields {
+		err := p.Repository.Hook(ctx, field)
+		if err != nil {
+			// Strip the path of the field, the key being reported.
+			if unwrapped := errors.Unwrap(err); unwrapped != nil {
+				err = unwrapped
+			}
+			errs[field.ConfigurationKey] = err
+		}
+	}
+
+	reports, err := p.Repository.Report(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, report := range reports {
+		if err, ok := errs[report.Key]; ok {
+			reports[i].Value = ""
+			reports[i].Error = err
+		}
+	}
+
+	return reports, nil
+}
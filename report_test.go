@@ -0,0 +1,176 @@
+package zconfig
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type priorityProvider struct {
+	TestProvider
+	priority int
+}
+
+func (p priorityProvider) Priority() int {
+	return p.priority
+}
+
+func TestRepositoryReport(t *testing.T) {
+	var repository Repository
+	repository.AddProviders(
+		priorityProvider{TestProvider{name: "file", values: map[string]string{"a": "file-a", "b": "file-b", "password": "old"}}, 3},
+		priorityProvider{TestProvider{name: "env", values: map[string]string{"a": "env-a", "password": "s3cr3t"}}, 2},
+	)
+	repository.AddParsers(ParseString)
+
+	values, err := repository.RetrieveAll("a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Retrieved{{Provider: "env", Value: "env-a"}, {Provider: "file", Value: "file-a"}}
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("unexpected values: %+v", values)
+	}
+
+	p := NewProcessor(repository.Hook)
+	p.Repository = &repository
+
+	var s struct {
+		B        string `key:"b"`
+		A        string `key:"a"`
+		C        int    `key:"c" default:"3"`
+		Password string `key:"password" secret:"true"`
+	}
+
+	var buf bytes.Buffer
+	_, fields, err := prepare(&s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = p.reportSources(context.Background(), &buf, fields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reports, err := repository.Report(fields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedReports := []KeyReport{
+		{Key: "a", Value: "env-a", Provider: "env", Shadowed: []Retrieved{{Provider: "file", Value: "file-a"}}},
+		{Key: "b", Value: "file-b", Provider: "file"},
+		{Key: "c", Value: "3", Provider: ProviderDefault},
+		{Key: "password", Value: Redacted, Provider: "env", Shadowed: []Retrieved{{Provider: "file", Value: Redacted}}},
+	}
+	if !reflect.DeepEqual(reports, expectedReports) {
+		t.Fatalf("unexpected reports: %+v", reports)
+	}
+
+	output := buf.String()
+	for _, line := range []string{"KEY", "env-a", "file=file-a", "default"} {
+		if !strings.Contains(output, line) {
+			t.Errorf("missing %q from output:\n%s", line, output)
+		}
+	}
+	for _, secret := range []string{"s3cr3t", "old"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("secret %q displayed in output:\n%s", secret, output)
+		}
+	}
+}
+
+func TestReportSourcesErrors(t *testing.T) {
+	var repository Repository
+	repository.AddProviders(TestProvider{name: "env", values: map[string]string{"port": "abc", "host": "localhost"}})
+	repository.AddParsers(ParseString)
+
+	p := NewProcessor(repository.Hook)
+	p.Repository = &repository
+
+	var s struct {
+		Host    string `key:"host"`
+		Port    int    `key:"port"`
+		Timeout int    `key:"timeout"`
+	}
+	_, fields, err := prepare(&s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reports, err := p.sourceReports(context.Background(), fields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reports) != 3 {
+		t.Fatalf("unexpected reports: %+v", reports)
+	}
+
+	if reports[0].Key != "host" || reports[0].Value != "localhost" || reports[0].Error != nil {
+		t.Errorf("unexpected report for host: %+v", reports[0])
+	}
+	if reports[1].Key != "port" || reports[1].Value != "" || reports[1].Provider != "" || reports[1].Error == nil {
+		t.Errorf("unexpected report for port: %+v", reports[1])
+	}
+	if reports[2].Key != "timeout" || reports[2].Error == nil || !strings.Contains(reports[2].Error.Error(), "missing key timeout") {
+		t.Errorf("unexpected report for timeout: %+v", reports[2])
+	}
+
+	var buf bytes.Buffer
+	err = WriteReport(&buf, reports)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "error: parsing value for key port") {
+		t.Errorf("missing error from output:\n%s", buf.String())
+	}
+}
+
+func TestConfigSourcesWithoutRepository(t *testing.T) {
+	Args.Args["config-sources"] = ""
+	defer delete(Args.Args, "config-sources")
+
+	var initialized bool
+	p := NewProcessor(func(ctx context.Context, f *Field) error {
+		initialized = true
+		return nil
+	})
+
+	err := p.Process(context.Background(), new(struct {
+		A string `key:"a"`
+	}))
+	if err == nil || !strings.Contains(err.Error(), "no repository") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if initialized {
+		t.Fatal("hooks executed")
+	}
+}
+
+func TestRepositoryReportValues(t *testing.T) {
+	var repository Repository
+	repository.AddProviders(TestProvider{name: "env", values: map[string]string{"pattern": "^a$", "tags": "a,b"}})
+	repository.AddParsers(ParseString)
+
+	var s struct {
+		Pattern regexp.Regexp `key:"pattern"`
+		Tags    []string      `key:"tags"`
+	}
+	fields, err := NewProcessor(repository.Hook).process(context.Background(), &s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reports, err := repository.Report(fields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The values are displayed as by Dump.
+	if len(reports) != 2 || reports[0].Value != "^a$" || reports[1].Value != "a,b" {
+		t.Fatalf("unexpected reports: %+v", reports)
+	}
+}
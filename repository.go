@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 	"sync"
 )
 
@@ -13,6 +14,11 @@ const ProviderDefault = "default"
 
 // A Repository is list of configuration providers and hooks.
 type Repository struct {
+	// RefuseSecretArgs makes the hook refuse the values of secret fields
+	// coming from the command-line arguments, which are visible to anyone
+	// on the host (through ps for example.)
+	RefuseSecretArgs bool
+
 	lock      sync.Mutex
 	providers []Provider
 	parsers   []Parser
@@ -28,6 +34,15 @@ func (r *Repository) AddProviders(providers ...Provider) {
 	})
 }
 
+func (r *Repository) hasProvider(name string) bool {
+	for _, p := range r.providers {
+		if p.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
 // Retrieve a key from the provider, by priority order.
 func (r *Repository) Retrieve(key string) (value interface{}, provider string, found bool, err error) {
 	for _, p := range r.providers {
@@ -43,6 +58,50 @@ func (r *Repository) Retrieve(key string) (value interface{}, provider string, f
 	return nil, "", false, nil
 }
 
+// Retrieve the value of a field from the providers it allows, by priority
+// order. If the value is only found in providers that aren't allowed, an
+// error naming them is returned.
+func (r *Repository) retrieve(f *Field) (value interface{}, provider string, found bool, err error) {
+	sources := f.Sources()
+	if sources == nil {
+		return r.Retrieve(f.ConfigurationKey)
+	}
+
+	var refused []Provider
+	for _, p := range r.providers {
+		if !contains(sources, p.Name()) {
+			refused = append(refused, p)
+			continue
+		}
+
+		value, found, err = p.Retrieve(f.ConfigurationKey)
+		if err != nil {
+			return nil, p.Name(), false, err
+		}
+		if found {
+			return value, p.Name(), true, nil
+		}
+	}
+
+	for _, p := range refused {
+		_, found, err = p.Retrieve(f.ConfigurationKey)
+		if err == nil && found {
+			return nil, p.Name(), false, fmt.Errorf("provider %s is not allowed for this key (allowed: %s)", p.Name(), strings.Join(sources, ", "))
+		}
+	}
+
+	return nil, "", false, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 var ErrNotParseable = errors.New("not parseable")
 
 // Register allow anyone to add a custom parser to the list.
@@ -53,8 +112,19 @@ func (r *Repository) AddParsers(parsers ...Parser) {
 }
 
 // Parse the parameter depending on the kind of the field, returning an
-// appropriately typed reflect.Value.
+// appropriately typed reflect.Value. The value wrapped by a Dynamic is parsed
+// then stored in it.
 func (r *Repository) Parse(raw, res interface{}) (err error) {
+	if d, ok := res.(dynamic); ok {
+		v := d.newValue()
+		err = r.Parse(raw, v)
+		if err != nil {
+			return err
+		}
+		d.store(reflect.ValueOf(v).Elem().Interface())
+		return nil
+	}
+
 	for _, p := range r.parsers {
 		err = p(raw, res)
 		if err == ErrNotParseable {
@@ -68,36 +138,197 @@ func (r *Repository) Parse(raw, res interface{}) (err error) {
 	return fmt.Errorf("no parser for type %T", res)
 }
 
+// Check the tags of a configurable field against the repository without
+// modifying its value: the sources, if any, must be registered providers and
+// the default value, if any, must be parseable.
+func (r *Repository) Check(f *Field) error {
+	if !f.Configurable {
+		return nil
+	}
+
+	for _, source := range f.Sources() {
+		if !r.hasProvider(source) {
+			return fmt.Errorf("unknown source %s for key %s", source, f.ConfigurationKey)
+		}
+	}
+
+	for _, value := range f.Enum() {
+		err := r.Parse(value, f.newValue())
+		if err != nil {
+			return fmt.Errorf("parsing enum value %s for key %s: %w", value, f.ConfigurationKey, err)
+		}
+	}
+
+	def, ok := f.Tags.Lookup(TagDefault)
+	if !ok {
+		return nil
+	}
+
+	res := f.newValue()
+	err := r.Parse(def, res)
+	if err != nil {
+		if f.IsSecret() {
+			err = redact(err, def)
+		}
+		return fmt.Errorf("parsing default value for key %s: %w", f.ConfigurationKey, err)
+	}
+
+	err = r.checkEnum(f, res)
+	if err != nil {
+		return fmt.Errorf("invalid default value for key %s: %w", f.ConfigurationKey, err)
+	}
+
+	return nil
+}
+
+// Check that the parsed value of the field is one of the values allowed by
+// its enum tag, if any.
+func (r *Repository) checkEnum(f *Field, res interface{}) error {
+	values := f.Enum()
+	if values == nil {
+		return nil
+	}
+
+	for _, value := range values {
+		allowed := f.newValue()
+		err := r.Parse(value, allowed)
+		if err != nil {
+			return fmt.Errorf("parsing enum value %s: %w", value, err)
+		}
+
+		if reflect.DeepEqual(valueOf(allowed), valueOf(res)) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("expected one of %s", strings.Join(values, ", "))
+}
+
 func (r *Repository) Hook(ctx context.Context, f *Field) (err error) {
 	if !f.Configurable {
 		return nil
 	}
 
-	raw, provider, found, err := r.Retrieve(f.ConfigurationKey)
+	var val = f.Value
+	if val.Kind() != reflect.Ptr {
+		val = val.Addr()
+	}
+
+	provider, err := r.load(f, val.Interface())
+	if err != nil {
+		return fmt.Errorf("configuring field %s: %w", f.Path, err)
+	}
+
+	if provider != "" {
+		f.Provider = provider
+	}
+
+	return nil
+}
+
+// Load the value of a configurable field into res, a pointer to a value of
+// the type of the field, and return the name of the provider it came from.
+// The name is empty if the key is missing from a disabled branch, in which
+// case res is left untouched.
+func (r *Repository) load(f *Field, res interface{}) (provider string, err error) {
+	raw, provider, found, err := r.retrieve(f)
 	if err != nil {
-		return fmt.Errorf("configuring field %s: retrieving key %s: %w", f.Path, f.ConfigurationKey, err)
+		return "", fmt.Errorf("retrieving key %s: %w", f.ConfigurationKey, err)
 	}
 
 	if !found {
 		def, ok := f.Tags.Lookup(TagDefault)
+		if !ok && !f.IsEnabled() {
+			// Missing keys of disabled branches aren't required.
+			return "", nil
+		}
 		if !ok {
-			return fmt.Errorf("configuring field %s: missing key %s", f.Path, f.ConfigurationKey)
+			return "", fmt.Errorf("missing key %s", f.ConfigurationKey)
 		}
 		raw = def
 		provider = ProviderDefault
 	}
 
-	var val = f.Value
-	if val.Kind() != reflect.Ptr {
-		val = val.Addr()
+	if f.IsSecret() && r.RefuseSecretArgs && provider == Args.Name() {
+		return "", fmt.Errorf("secret key %s must not be given as a command-line argument", f.ConfigurationKey)
+	}
+
+	err = r.Parse(raw, res)
+	if err != nil {
+		if f.IsSecret() {
+			err = redact(err, raw)
+		}
+		return "", fmt.Errorf("parsing value for key %s: %w", f.ConfigurationKey, err)
 	}
 
-	err = r.Parse(raw, val.Interface())
+	err = r.checkEnum(f, res)
 	if err != nil {
-		return fmt.Errorf("configuring field %s: parsing value for key %s: %w", f.Path, f.ConfigurationKey, err)
+		display := raw
+		if f.IsSecret() {
+			display = Redacted
+		}
+		return "", fmt.Errorf("invalid value %v for key %s: %w", display, f.ConfigurationKey, err)
 	}
 
-	f.Provider = provider
+	return provider, nil
+}
 
-	return nil
+// Watch returns a channel receiving a value every time one of the providers
+// implementing Watcher signals a change, until the context is done.
+func (r *Repository) Watch(ctx context.Context) <-chan struct{} {
+	r.lock.Lock()
+	var watchers []Watcher
+	for _, p := range r.providers {
+		if w, ok := p.(Watcher); ok {
+			watchers = append(watchers, w)
+		}
+	}
+	r.lock.Unlock()
+
+	changes := make(chan struct{}, 1)
+	for _, w := range watchers {
+		go func(events <-chan struct{}) {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case _, ok := <-events:
+					if !ok {
+						return
+					}
+
+					// Coalesce the changes not consumed yet.
+					select {
+					case changes <- struct{}{}:
+					default:
+					}
+				}
+			}
+		}(w.Watch(ctx))
+	}
+
+	return changes
+}
+
+// A redactedError hides a secret value from the message of the error it
+// wraps.
+type redactedError struct {
+	err    error
+	secret string
+}
+
+func redact(err error, raw interface{}) error {
+	secret, ok := raw.(string)
+	if !ok || secret == "" {
+		return err
+	}
+	return redactedError{err: err, secret: secret}
+}
+
+func (e redactedError) Error() string {
+	return strings.ReplaceAll(e.err.Error(), e.secret, Redacted)
+}
+
+func (e redactedError) Unwrap() error {
+	return e.err
 }
@@ -0,0 +1,229 @@
+package zconfig
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRepositoryHook(t *testing.T) {
+	walkField := func(t *testing.T, s interface{}) *Field {
+		_, fields, err := prepare(s)
+		if err != nil {
+			t.Fatalf("preparing struct: %s", err)
+		}
+		for _, f := range fields {
+			if f.Configurable {
+				return f
+			}
+		}
+		t.Fatal("no configurable field")
+		return nil
+	}
+
+	t.Run("secret parse error", func(t *testing.T) {
+		var r Repository
+		r.AddParsers(ParseString)
+		r.AddProviders(TestProvider{"test", map[string]string{"password": "hunter2"}})
+
+		f := walkField(t, new(struct {
+			Password int `key:"password" secret:"true"`
+		}))
+
+		err := r.Hook(context.Background(), f)
+		if err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+		if strings.Contains(err.Error(), "hunter2") {
+			t.Fatalf("secret leaked in error: %v", err)
+		}
+		if !strings.Contains(err.Error(), Redacted) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("secret from args", func(t *testing.T) {
+		var r = Repository{RefuseSecretArgs: true}
+		r.AddParsers(ParseString)
+		r.AddProviders(&ArgsProvider{Args: map[string]string{"password": "hunter2"}})
+
+		f := walkField(t, new(struct {
+			Password string `key:"password" secret:"true"`
+		}))
+
+		err := r.Hook(context.Background(), f)
+		if err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+		if !strings.Contains(err.Error(), "must not be given as a command-line argument") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("sources", func(t *testing.T) {
+		var r Repository
+		r.AddParsers(ParseString)
+		r.AddProviders(
+			TestProvider{"args", map[string]string{"token": "from-args", "only-args": "from-args"}},
+			TestProvider{"env", map[string]string{"token": "from-env"}},
+		)
+
+		var s struct {
+			Token    string `key:"token" sources:"env"`
+			OnlyArgs string `key:"only-args" sources:"env"`
+			Default  string `key:"default" sources:"env" default:"from-default"`
+		}
+		_, fields, err := prepare(&s)
+		if err != nil {
+			t.Fatalf("preparing struct: %s", err)
+		}
+
+		var errs = make(map[string]error)
+		for _, f := range fields {
+			errs[f.Path] = r.Hook(context.Background(), f)
+		}
+
+		if err := errs["$.Token"]; err != nil || s.Token != "from-env" {
+			t.Errorf("unexpected value %q for token: %v", s.Token, err)
+		}
+
+		if err := errs["$.OnlyArgs"]; err == nil || !strings.Contains(err.Error(), "provider args is not allowed") {
+			t.Errorf("unexpected error for only-args: %v", err)
+		}
+
+		if err := errs["$.Default"]; err != nil || s.Default != "from-default" {
+			t.Errorf("unexpected value %q for default: %v", s.Default, err)
+		}
+	})
+
+	t.Run("enum", func(t *testing.T) {
+		var r Repository
+		r.AddParsers(ParseString)
+		r.AddProviders(TestProvider{"test", map[string]string{"format": "xml", "level": "2"}})
+
+		var s struct {
+			Format string `key:"format" enum:"json,text,logfmt"`
+			Level  int    `key:"level" enum:"1, 2, 3"`
+		}
+		_, fields, err := prepare(&s)
+		if err != nil {
+			t.Fatalf("preparing struct: %s", err)
+		}
+
+		var errs = make(map[string]error)
+		for _, f := range fields {
+			errs[f.Path] = r.Hook(context.Background(), f)
+		}
+
+		if err := errs["$.Format"]; err == nil || !strings.Contains(err.Error(), "invalid value xml for key format: expected one of json, text, logfmt") {
+			t.Errorf("unexpected error for format: %v", err)
+		}
+
+		if err := errs["$.Level"]; err != nil || s.Level != 2 {
+			t.Errorf("unexpected value %d for level: %v", s.Level, err)
+		}
+	})
+}
+
+func TestRepositoryCheck(t *testing.T) {
+	var r Repository
+	r.AddParsers(ParseString)
+	r.AddProviders(Env)
+
+	for name, tCase := range map[string]struct {
+		s   interface{}
+		err bool
+	}{
+		"valid default": {s: new(struct {
+			Foo int `key:"foo" default:"1"`
+		}), err: false},
+		"invalid default": {s: new(struct {
+			Foo int `key:"foo" default:"a"`
+		}), err: true},
+		"no default": {s: new(struct {
+			Foo int `key:"foo"`
+		}), err: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			root, err := walk(reflect.ValueOf(tCase.s), reflect.StructField{}, nil)
+			if err != nil {
+				t.Fatalf("walking struct: %s", err)
+			}
+			mark(root, "")
+
+			err = r.Check(root.Children[0])
+			if (err != nil) != tCase.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+type enabledTest struct {
+	Enabled  bool   `key:"enabled" default:"false"`
+	Endpoint string `key:"endpoint"`
+
+	initialized bool
+}
+
+func (e *enabledTest) Init(ctx context.Context) error {
+	e.initialized = true
+	return nil
+}
+
+func TestRepositoryEnabled(t *testing.T) {
+	for name, tCase := range map[string]struct {
+		values  map[string]string
+		enabled bool
+		err     string
+	}{
+		"disabled":         {values: map[string]string{}, enabled: false},
+		"enabled":          {values: map[string]string{"tracing.enabled": "true", "tracing.endpoint": "localhost"}, enabled: true},
+		"enabled, missing": {values: map[string]string{"tracing.enabled": "true"}, err: "missing key tracing.endpoint"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var r Repository
+			r.AddParsers(ParseString)
+			r.AddProviders(TestProvider{"test", tCase.values})
+
+			var s struct {
+				Tracing *enabledTest `key:"tracing" enabled:"tracing.enabled"`
+			}
+			err := NewProcessor(r.Hook, Initialize).Process(context.Background(), &s)
+			if tCase.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tCase.err) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if s.Tracing.initialized != tCase.enabled {
+				t.Fatalf("unexpected initialization: wanted %v, got %v", tCase.enabled, s.Tracing.initialized)
+			}
+		})
+	}
+
+	t.Run("unknown key", func(t *testing.T) {
+		var s struct {
+			Tracing *enabledTest `key:"tracing" enabled:"tracing.disabled"`
+		}
+		err := NewProcessor().Process(context.Background(), &s)
+		if err == nil || !strings.Contains(err.Error(), "unknown key tracing.disabled in enabled tag of field $.Tracing") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("not a boolean", func(t *testing.T) {
+		var s struct {
+			Tracing *enabledTest `key:"tracing" enabled:"tracing.endpoint"`
+		}
+		err := NewProcessor().Process(context.Background(), &s)
+		if err == nil || !strings.Contains(err.Error(), "is not a boolean") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package zconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+)
+
+// Runnable is the interface implemented by the long-running components of a
+// service, like servers or consumers. Run is expected to block until the
+// given context is done.
+type Runnable interface {
+	Run(context.Context) error
+}
+
+// Used for type comparison.
+var typeRunnable = reflect.TypeOf((*Runnable)(nil)).Elem()
+
+// Run configures and initializes the given service, then runs concurrently
+// every field implementing Runnable, except those disabled or wrapped in a Lazy
+// not initialized yet, until they all returned, the context is done, the
+// process receives SIGINT or SIGTERM, or one of them fails, in which case the
+// others are canceled. Once they all returned, the processor is shut down and
+// the initialized fields are closed in reverse order.
+func (p *Processor) Run(ctx context.Context, s interface{}) error {
+	fields, err := p.process(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	runCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	runCtx, cancel := context.WithCancel(runCtx)
+	defer cancel()
+
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+	for _, field := range fields {
+		if !field.active() || !field.Value.Type().Implements(typeRunnable) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(field *Field) {
+			defer wg.Done()
+
+			err := field.Value.Interface().(Runnable).Run(runCtx)
+
+			// Returning the context error once it is done is the
+			// expected way to stop.
+			if err == nil || (runCtx.Err() != nil && errors.Is(err, runCtx.Err())) {
+				return
+			}
+
+			once.Do(func() {
+				first = fmt.Errorf("running field %s: %w", field.Path, err)
+				cancel()
+			})
+		}(field)
+	}
+
+	// Stop once every field returned, even without error.
+	go func() {
+		wg.Wait()
+		cancel()
+	}()
+
+	<-runCtx.Done()
+	wg.Wait()
+
+	shutdownCtx := context.WithoutCancel(ctx)
+	if p.ShutdownTimeout != 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, p.ShutdownTimeout)
+		defer cancel()
+	}
+
+	err = p.Shutdown(shutdownCtx)
+	if err != nil {
+		return errors.Join(first, fmt.Errorf("shutting down: %w", err))
+	}
+	return first
+}
@@ -0,0 +1,132 @@
+package zconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type runTest struct {
+	name string
+	log  *lifecycleLog
+	lock *sync.Mutex
+	err  error
+	job  bool
+}
+
+func (r *runTest) append(event string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	*r.log = append(*r.log, event)
+}
+
+func (r *runTest) Init(ctx context.Context) error {
+	r.append("init " + r.name)
+	return nil
+}
+
+func (r *runTest) Run(ctx context.Context) error {
+	if r.err != nil {
+		time.Sleep(10 * time.Millisecond)
+		r.append("fail " + r.name)
+		return r.err
+	}
+
+	if r.job {
+		r.append("done " + r.name)
+		return nil
+	}
+
+	<-ctx.Done()
+	r.append("stop " + r.name)
+	return ctx.Err()
+}
+
+func (r *runTest) Close(ctx context.Context) error {
+	r.append("close " + r.name)
+	return nil
+}
+
+func TestProcessorRun(t *testing.T) {
+	var (
+		log  lifecycleLog
+		lock sync.Mutex
+	)
+
+	t.Run("failure", func(t *testing.T) {
+		log = nil
+		s := struct {
+			Server   *runTest
+			Consumer *struct {
+				Worker *runTest
+			}
+		}{
+			Server: &runTest{name: "server", log: &log, lock: &lock},
+			Consumer: &struct {
+				Worker *runTest
+			}{&runTest{name: "worker", log: &log, lock: &lock, err: errors.New("failed")}},
+		}
+
+		err := NewProcessor(Initialize).Run(context.Background(), &s)
+		if err == nil || !strings.HasPrefix(err.Error(), "running field $.Consumer.Worker: failed") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// The fields are closed in the reverse order of their
+		// initialization.
+		expected := lifecycleLog{"fail worker", "stop server", "close " + log[1][len("init "):], "close " + log[0][len("init "):]}
+		if !reflect.DeepEqual(log[2:], expected) {
+			t.Fatalf("unexpected lifecycle: wanted %v, got %v", expected, log[2:])
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		log = nil
+		s := struct {
+			Server *runTest
+		}{
+			Server: &runTest{name: "server", log: &log, lock: &lock},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		err := NewProcessor(Initialize).Run(ctx, &s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := lifecycleLog{"init server", "stop server", "close server"}
+		if !reflect.DeepEqual(log, expected) {
+			t.Fatalf("unexpected lifecycle: wanted %v, got %v", expected, log)
+		}
+	})
+	t.Run("done", func(t *testing.T) {
+		log = nil
+		s := struct {
+			Job *runTest
+		}{
+			Job: &runTest{name: "job", log: &log, lock: &lock, job: true},
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		err := NewProcessor(Initialize).Run(ctx, &s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ctx.Err() != nil {
+			t.Fatal("run returned on the context deadline")
+		}
+
+		expected := lifecycleLog{"init job", "done job", "close job"}
+		if !reflect.DeepEqual(log, expected) {
+			t.Fatalf("unexpected lifecycle: wanted %v, got %v", expected, log)
+		}
+	})
+}
@@ -0,0 +1,257 @@
+package zconfig
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats supported by GenerateSample, in addition to FormatYAML and
+// FormatEnv.
+const (
+	FormatTOML = "toml"
+)
+
+// GenerateSample generates a sample configuration file in one of the yaml,
+// toml or env formats, listing the configurable fields among the given
+// fields in declaration order, nested by key. Each key is preceded by its
+// description as a comment and set to its default value; the required keys
+// are commented out. The TOML values are typed after the fields, the values
+// parsed from their textual form, like durations, being strings.
+func GenerateSample(fields []*Field, format string) ([]byte, error) {
+	root := newKeyTree(fields)
+
+	var buf bytes.Buffer
+	switch format {
+	case FormatYAML:
+		writeYAMLSample(&buf, root, 0)
+	case FormatTOML:
+		writeTOMLSample(&buf, root, nil)
+	case FormatEnv:
+		writeEnvSample(&buf, root)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+
+	return bytes.TrimLeft(buf.Bytes(), "\n"), nil
+}
+
+// A node of the tree of the configuration keys, used to generate files and
+// documentation: either a configurable field, or a section grouping the keys
+// sharing a prefix.
+type keyNode struct {
+	name        string
+	field       *Field
+	description string
+	children    []*keyNode
+}
+
+func (n *keyNode) child(name string) *keyNode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+
+	c := &keyNode{name: name}
+	n.children = append(n.children, c)
+	return c
+}
+
+// Build the tree of the keys of the given fields, in declaration order.
+func newKeyTree(fields []*Field) *keyNode {
+	root := &keyNode{}
+	if len(fields) == 0 {
+		return root
+	}
+
+	top := fields[0]
+	for top.Parent != nil {
+		top = top.Parent
+	}
+
+	for _, f := range flatten(top) {
+		var key string
+		switch {
+		case f.Configurable:
+			key = f.ConfigurationKey
+		case f.Key != "" && len(f.Children) != 0:
+			key = sectionKey(f)
+		default:
+			continue
+		}
+
+		node := root
+		for _, part := range strings.Split(key, ".") {
+			node = node.child(part)
+		}
+
+		if f.Configurable {
+			node.field = f
+		} else {
+			node.description, _ = f.Tags.Lookup(TagDescription)
+		}
+	}
+
+	return root
+}
+
+// Return the key prefix of the children of a keyed struct.
+func sectionKey(f *Field) string {
+	var parts []string
+	for a := f; a != nil; a = a.Parent {
+		if a.Key != "" {
+			parts = append([]string{a.Key}, parts...)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// Return the comment and the default value of a configurable field, and
+// whether the key should be set in the sample: the required keys and the
+// secrets are commented out.
+func sampleValue(f *Field) (comment, value string, set bool) {
+	comment = describe(f)
+	value, set = f.Tags.Lookup(TagDefault)
+	if !set {
+		comment = strings.TrimSpace(comment + " (required)")
+	}
+	if f.IsSecret() {
+		comment = strings.TrimSpace(comment + " (secret)")
+		value, set = "", false
+	}
+	return comment, value, set
+}
+
+func writeComment(buf *bytes.Buffer, indent, comment string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		fmt.Fprintf(buf, "%s# %s\n", indent, line)
+	}
+}
+
+func writeYAMLSample(buf *bytes.Buffer, node *keyNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, c := range node.children {
+		if c.field == nil {
+			buf.WriteString("\n")
+			writeComment(buf, indent, c.description)
+			fmt.Fprintf(buf, "%s%s:\n", indent, c.name)
+			writeYAMLSample(buf, c, depth+1)
+			continue
+		}
+
+		comment, value, ok := sampleValue(c.field)
+		writeComment(buf, indent, comment)
+		if ok {
+			fmt.Fprintf(buf, "%s%s: %s\n", indent, c.name, quoteYAML(value))
+		} else {
+			fmt.Fprintf(buf, "%s# %s:\n", indent, c.name)
+		}
+	}
+}
+
+// Quote a scalar for YAML if it wouldn't be read back as is.
+func quoteYAML(value string) string {
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte("k: "+value), &node)
+	if err == nil && len(node.Content) == 1 && len(node.Content[0].Content) == 2 {
+		scalar := node.Content[0].Content[1]
+		if scalar.Kind == yaml.ScalarNode && scalar.ShortTag() != "!!null" && scalar.Value == value {
+			return value
+		}
+	}
+	return strconv.Quote(value)
+}
+
+func writeTOMLSample(buf *bytes.Buffer, node *keyNode, path []string) {
+	// The keys of a table must be written before its sub-tables.
+	for _, c := range node.children {
+		if c.field == nil {
+			continue
+		}
+
+		comment, value, ok := sampleValue(c.field)
+		writeComment(buf, "", comment)
+		if ok {
+			fmt.Fprintf(buf, "%s = %s\n", c.name, tomlValue(schemaValue(c.field, value)))
+		} else {
+			fmt.Fprintf(buf, "# %s = \"\"\n", c.name)
+		}
+	}
+
+	for _, c := range node.children {
+		if c.field != nil {
+			continue
+		}
+
+		table := append(path[:len(path):len(path)], c.name)
+		buf.WriteString("\n")
+		writeComment(buf, "", c.description)
+		fmt.Fprintf(buf, "[%s]\n", strings.Join(table, "."))
+		writeTOMLSample(buf, c, table)
+	}
+}
+
+// Format a value typed by schemaValue as TOML, the values parsed from their
+// textual form being strings.
+func tomlValue(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v)
+	case float32:
+		return tomlFloat(float64(v), 32)
+	case float64:
+		return tomlFloat(v, 64)
+	case []interface{}:
+		var items []string
+		for _, item := range v {
+			items = append(items, tomlValue(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return strconv.Quote(fmt.Sprint(v))
+	}
+}
+
+// Format a float as TOML, which requires a fractional part or an exponent.
+func tomlFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 0):
+		return strings.TrimSuffix(strconv.FormatFloat(f, 'g', -1, bitSize), "Inf") + "inf"
+	}
+
+	s := strconv.FormatFloat(f, 'g', -1, bitSize)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+func writeEnvSample(buf *bytes.Buffer, node *keyNode) {
+	for _, c := range node.children {
+		if c.field == nil {
+			writeEnvSample(buf, c)
+			continue
+		}
+
+		name := Env.FormatKey(c.field.ConfigurationKey)
+		comment, value, ok := sampleValue(c.field)
+		writeComment(buf, "", comment)
+		if ok {
+			fmt.Fprintf(buf, "%s=%s\n", name, quoteEnv(value))
+		} else {
+			fmt.Fprintf(buf, "# %s=\n", name)
+		}
+	}
+}
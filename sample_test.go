@@ -0,0 +1,140 @@
+package zconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type sampleTest struct {
+	Name  string `key:"name" default:"my service" description:"Name of the service"`
+	Level string `key:"level" default:"info" enum:"debug,info"`
+	DB    struct {
+		Host     string `key:"host" description:"Host of the database"`
+		Port     int    `key:"port" default:"5432"`
+		Password string `key:"password" default:"admin" secret:"true"`
+		Pool     struct {
+			Size int `key:"size" default:"10"`
+		} `key:"pool"`
+	} `key:"db" description:"Database connection"`
+	Ratio   string        `key:"ratio" default:"1: 2"`
+	Debug   bool          `key:"debug" default:"false"`
+	Timeout time.Duration `key:"timeout" default:"1s"`
+	Rate    float64       `key:"rate" default:"1"`
+	Tags    []string      `key:"tags" default:"a,b"`
+}
+
+func TestGenerateSample(t *testing.T) {
+	_, fields, err := prepare(new(sampleTest))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for format, expected := range map[string]string{
+		FormatYAML: `# Name of the service
+name: my service
+# (one of: debug, info)
+level: info
+
+# Database connection
+db:
+  # Host of the database (required)
+  # host:
+  port: 5432
+  # (secret)
+  # password:
+
+  pool:
+    size: 10
+ratio: "1: 2"
+debug: false
+timeout: 1s
+rate: 1
+tags: a,b
+`,
+		FormatTOML: `# Name of the service
+name = "my service"
+# (one of: debug, info)
+level = "info"
+ratio = "1: 2"
+debug = false
+timeout = "1s"
+rate = 1.0
+tags = ["a", "b"]
+
+# Database connection
+[db]
+# Host of the database (required)
+# host = ""
+port = 5432
+# (secret)
+# password = ""
+
+[db.pool]
+size = 10
+`,
+		FormatEnv: `# Name of the service
+NAME="my service"
+# (one of: debug, info)
+LEVEL=info
+# Host of the database (required)
+# DB_HOST=
+DB_PORT=5432
+# (secret)
+# DB_PASSWORD=
+DB_POOL_SIZE=10
+RATIO="1: 2"
+DEBUG=false
+TIMEOUT=1s
+RATE=1
+TAGS=a,b
+`,
+	} {
+		raw, err := GenerateSample(fields, format)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", format, err)
+		}
+		if string(raw) != expected {
+			t.Errorf("unexpected %s sample:\n%s", format, raw)
+		}
+	}
+
+	_, err = GenerateSample(fields, "xml")
+	if err == nil {
+		t.Fatal("expected an error for an unknown format, got nil")
+	}
+
+	t.Run("file provider", func(t *testing.T) {
+		for format, file := range map[string]string{
+			FormatYAML: "config.yaml",
+			FormatEnv:  "config.env",
+		} {
+			raw, err := GenerateSample(fields, format)
+			if err != nil {
+				t.Fatalf("unexpected error for %s: %v", format, err)
+			}
+
+			path := filepath.Join(t.TempDir(), file)
+			err = os.WriteFile(path, raw, 0o600)
+			if err != nil {
+				t.Fatalf("writing file: %s", err)
+			}
+
+			provider, err := NewFileProvider(path)
+			if err != nil {
+				t.Fatalf("reading %s sample: %v", format, err)
+			}
+
+			for key, expected := range map[string]string{"name": "my service", "db.pool.size": "10", "ratio": "1: 2"} {
+				value, found, _ := provider.Retrieve(key)
+				if !found || value != expected {
+					t.Errorf("unexpected value for %s in %s sample: %v", key, format, value)
+				}
+			}
+			if _, found, _ := provider.Retrieve("db.host"); found {
+				t.Errorf("required key set in %s sample", format)
+			}
+		}
+	})
+}
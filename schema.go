@@ -0,0 +1,151 @@
+package zconfig
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"time"
+)
+
+// SchemaDraft is the JSON Schema dialect generated by JSONSchema.
+const SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	typeTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	typeDuration        = reflect.TypeOf(time.Duration(0))
+	typeRegexp          = reflect.TypeOf(regexp.Regexp{})
+)
+
+// JSONSchema generates the JSON Schema of the configuration files of the given
+// struct, whose nested objects match the dotted keys. The schema of each key
+// is derived from the type of its field and from its tags: description,
+// default and enum values, secret (as write-only) and required keys, the keys
+// of the branches that can be disabled being optional. The default and enum
+// values are typed using the parsers of the DefaultRepository.
+func JSONSchema(s interface{}) ([]byte, error) {
+	_, fields, err := prepare(s)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := objectSchema(newKeyTree(fields))
+	schema["$schema"] = SchemaDraft
+
+	raw, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding schema: %w", err)
+	}
+	return append(raw, '\n'), nil
+}
+
+// Return the schema of the object holding the keys of a node of the tree.
+func objectSchema(node *keyNode) map[string]interface{} {
+	var (
+		properties = make(map[string]interface{})
+		required   []string
+	)
+	for _, c := range node.children {
+		if c.field == nil {
+			child := objectSchema(c)
+			properties[c.name] = child
+			if _, ok := child["required"]; ok {
+				required = append(required, c.name)
+			}
+			continue
+		}
+
+		properties[c.name] = fieldSchema(c.field)
+		if _, ok := c.field.Tags.Lookup(TagDefault); !ok && c.field.Condition() == "" {
+			required = append(required, c.name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if node.description != "" {
+		schema["description"] = node.description
+	}
+	if required != nil {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// Return the schema of the value of a configurable field.
+func fieldSchema(f *Field) map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(valueOf(f.newValue())))
+
+	if desc, ok := f.Tags.Lookup(TagDescription); ok {
+		schema["description"] = desc
+	}
+
+	if f.IsSecret() {
+		schema["writeOnly"] = true
+	} else if def, ok := f.Tags.Lookup(TagDefault); ok {
+		schema["default"] = schemaValue(f, def)
+	}
+
+	if values := f.Enum(); values != nil {
+		var enum []interface{}
+		for _, value := range values {
+			enum = append(enum, schemaValue(f, value))
+		}
+		schema["enum"] = enum
+	}
+
+	return schema
+}
+
+// Return the schema of a type, the types parsed from their textual form
+// being strings.
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t == nil {
+		return make(map[string]interface{})
+	}
+
+	switch {
+	case t == typeDuration:
+		return map[string]interface{}{"type": "string", "pattern": `^([-+]?([0-9]+(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+|0)$`}
+	case t == typeRegexp:
+		return map[string]interface{}{"type": "string", "format": "regex"}
+	case reflect.PointerTo(t).Implements(typeTextUnmarshaler):
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string"}
+		}
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	default:
+		return make(map[string]interface{})
+	}
+}
+
+// Return the raw value of a tag as a value of the type of the field, or as
+// is if it can't be parsed.
+func schemaValue(f *Field, raw string) interface{} {
+	res := f.newValue()
+	err := DefaultRepository.Parse(raw, res)
+	if err != nil {
+		return raw
+	}
+	return dumpValue(reflect.ValueOf(valueOf(res)))
+}
@@ -0,0 +1,74 @@
+package zconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestJSONSchema(t *testing.T) {
+	var s struct {
+		Name    string        `key:"name" description:"Name of the service"`
+		Level   string        `key:"level" default:"info" enum:"debug,info"`
+		Workers uint          `key:"workers" default:"4"`
+		Timeout time.Duration `key:"timeout" default:"1m30s"`
+		Tags    []string      `key:"tags" default:"a,b"`
+		Ratio   *float64      `key:"ratio"`
+		Rate    Dynamic[int]  `key:"rate" default:"100"`
+		DB      struct {
+			Enabled  bool   `key:"enabled" default:"false"`
+			Host     string `key:"host" enabled:"db.enabled"`
+			Password string `key:"password" secret:"true"`
+		} `key:"db" description:"Database connection"`
+	}
+
+	raw, err := JSONSchema(&s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var actual map[string]interface{}
+	err = json.Unmarshal(raw, &actual)
+	if err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+
+	var expected map[string]interface{}
+	err = json.Unmarshal([]byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"required": ["name", "ratio", "db"],
+		"properties": {
+			"name": {"type": "string", "description": "Name of the service"},
+			"level": {"type": "string", "default": "info", "enum": ["debug", "info"]},
+			"workers": {"type": "integer", "minimum": 0, "default": 4},
+			"timeout": {"type": "string", "pattern": "^([-+]?([0-9]+(\\.[0-9]*)?(ns|us|µs|ms|s|m|h))+|0)$", "default": "1m30s"},
+			"tags": {"type": "array", "items": {"type": "string"}, "default": ["a", "b"]},
+			"ratio": {"type": "number"},
+			"rate": {"type": "integer", "default": 100},
+			"db": {
+				"type": "object",
+				"description": "Database connection",
+				"required": ["password"],
+				"properties": {
+					"enabled": {"type": "boolean", "default": false},
+					"host": {"type": "string"},
+					"password": {"type": "string", "writeOnly": true}
+				}
+			}
+		}
+	}`), &expected)
+	if err != nil {
+		t.Fatalf("invalid expected schema: %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected schema:\n%s", raw)
+	}
+
+	_, err = JSONSchema(struct{}{})
+	if err == nil {
+		t.Fatal("expected an error for a non pointer, got nil")
+	}
+}
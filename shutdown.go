@@ -0,0 +1,67 @@
+package zconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// Closer is the interface implemented by the fields that need to release
+// their resources when the service shuts down.
+type Closer interface {
+	Close(context.Context) error
+}
+
+// Used for type comparison.
+var typeCloser = reflect.TypeOf((*Closer)(nil)).Elem()
+var typeIOCloser = reflect.TypeOf((*io.Closer)(nil)).Elem()
+
+// Shutdown closes every field initialized by the processor, in the reverse
+// order of their initialization, via the Closer or io.Closer interface. The
+// fields not yet closed when the context is done are skipped. The errors of
+// all fields are aggregated in the returned error. The processed structs are
+// released, so their health isn't checked anymore.
+func (p *Processor) Shutdown(ctx context.Context) error {
+	p.lock.Lock()
+	fields := p.initialized
+	p.processed = nil
+	p.structs = 0
+	p.initialized = nil
+	p.lock.Unlock()
+
+	return closeFields(ctx, fields)
+}
+
+// Close the given fields in reverse order.
+func closeFields(ctx context.Context, fields []*Field) error {
+	var errs []error
+	for i := len(fields) - 1; i >= 0; i-- {
+		err := closeField(ctx, fields[i])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("closing field %s: %w", fields[i].Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func closeField(ctx context.Context, field *Field) error {
+	if l, ok := field.lazy(); ok {
+		return l.Close(ctx)
+	}
+
+	switch {
+	case field.Value.Type().Implements(typeCloser):
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return field.Value.Interface().(Closer).Close(ctx)
+	case field.Value.Type().Implements(typeIOCloser):
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return field.Value.Interface().(io.Closer).Close()
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package zconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type lifecycleLog []string
+
+type closeTest struct {
+	name string
+	log  *lifecycleLog
+	err  error
+}
+
+func (c *closeTest) Init(ctx context.Context) error {
+	*c.log = append(*c.log, "init "+c.name)
+	return nil
+}
+
+func (c *closeTest) Close(ctx context.Context) error {
+	*c.log = append(*c.log, "close "+c.name)
+	return c.err
+}
+
+type ioCloseTest struct {
+	closeTest
+	Child *closeTest
+}
+
+func (c *ioCloseTest) Close() error {
+	*c.log = append(*c.log, "close "+c.name)
+	return c.err
+}
+
+type notInitializedCloseTest struct {
+	closed bool
+}
+
+func (c *notInitializedCloseTest) Close(ctx context.Context) error {
+	c.closed = true
+	return nil
+}
+
+func TestProcessorShutdown(t *testing.T) {
+	var log lifecycleLog
+	var s = struct {
+		Parent    *ioCloseTest
+		NotInited *notInitializedCloseTest
+	}{
+		Parent: &ioCloseTest{
+			closeTest: closeTest{name: "parent", log: &log},
+			Child:     &closeTest{name: "child", log: &log, err: errors.New("failed")},
+		},
+	}
+
+	p := NewProcessor(Initialize)
+	err := p.Process(context.Background(), &s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = p.Shutdown(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "closing field $.Parent.Child: failed") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := lifecycleLog{"init child", "init parent", "close parent", "close child"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Fatalf("unexpected lifecycle: wanted %v, got %v", expected, log)
+	}
+
+	if s.NotInited.closed {
+		t.Fatal("non initialized field was closed")
+	}
+
+	t.Run("twice", func(t *testing.T) {
+		log = nil
+		err = p.Shutdown(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(log) != 0 {
+			t.Fatalf("unexpected lifecycle: %v", log)
+		}
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		log = nil
+		err := p.Process(context.Background(), &s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err = p.Shutdown(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(log, lifecycleLog{"init child", "init parent"}) {
+			t.Fatalf("unexpected lifecycle: %v", log)
+		}
+	})
+
+	t.Run("processed again", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			err := p.Process(context.Background(), &s)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if len(p.processed) != 1 {
+			t.Fatalf("unexpected number of processed structs: %d", len(p.processed))
+		}
+
+		// A struct with nothing to track isn't retained.
+		err := p.Process(context.Background(), new(struct {
+			Foo int `default:"1" key:"foo"`
+		}))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(p.processed) != 1 {
+			t.Fatalf("unexpected number of processed structs: %d", len(p.processed))
+		}
+
+		// Every initialization is matched by a close.
+		log = nil
+		_ = p.Shutdown(context.Background())
+		if !reflect.DeepEqual(log, lifecycleLog{"close parent", "close child", "close parent", "close child"}) {
+			t.Fatalf("unexpected lifecycle: %v", log)
+		}
+	})
+}
+
+type failingInitTest struct {
+	Child *closeTest
+}
+
+func (f *failingInitTest) Init(ctx context.Context) error {
+	return errors.New("init failed")
+}
+
+func TestProcessorRollback(t *testing.T) {
+	var log lifecycleLog
+	var s = struct {
+		Failing *failingInitTest
+	}{
+		Failing: &failingInitTest{
+			Child: &closeTest{name: "child", log: &log, err: errors.New("close failed")},
+		},
+	}
+
+	p := NewProcessor(Initialize)
+	err := p.Process(context.Background(), &s)
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+
+	if !strings.HasPrefix(err.Error(), "executing hook on field $.Failing: initializing field: init failed") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "closing field $.Failing.Child: close failed") {
+		t.Fatalf("close error not attached: %v", err)
+	}
+
+	expected := lifecycleLog{"init child", "close child"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Fatalf("unexpected lifecycle: wanted %v, got %v", expected, log)
+	}
+
+	// Rolled back fields must not be closed again.
+	log = nil
+	err = p.Shutdown(context.Background())
+	if err != nil || len(log) != 0 {
+		t.Fatalf("unexpected shutdown: %v, %v", err, log)
+	}
+}
@@ -2,6 +2,8 @@ package zconfig
 
 import (
 	"context"
+	"log"
+	"net/http"
 )
 
 var (
@@ -11,10 +13,15 @@ var (
 	Env               = NewEnvProvider()
 )
 
+// DefaultLogger is the logger used by zconfig to report events, like the
+// retries of field initializations.
+var DefaultLogger Logger = log.Default()
+
 func init() {
 	DefaultRepository.AddProviders(Args, Env)
 	DefaultRepository.AddParsers(ParseString)
 	DefaultProcessor.AddHooks(DefaultRepository.Hook, Initialize)
+	DefaultProcessor.Repository = &DefaultRepository
 }
 
 // Configure a service using the default processor.
@@ -22,6 +29,37 @@ func Configure(ctx context.Context, s interface{}) error {
 	return DefaultProcessor.Process(ctx, s)
 }
 
+// Run a service using the default processor.
+func Run(ctx context.Context, s interface{}) error {
+	return DefaultProcessor.Run(ctx, s)
+}
+
+// Watch a service configured by the default processor for changes.
+func Watch(ctx context.Context, s interface{}) error {
+	return DefaultProcessor.Watch(ctx, s)
+}
+
+// Shutdown the services configured by the default processor.
+func Shutdown(ctx context.Context) error {
+	return DefaultProcessor.Shutdown(ctx)
+}
+
+// CheckHealth of the services configured by the default processor.
+func CheckHealth(ctx context.Context) map[string]error {
+	return DefaultProcessor.CheckHealth(ctx)
+}
+
+// HealthHandler serving the health of the services configured by the default
+// processor.
+func HealthHandler() http.Handler {
+	return DefaultProcessor.HealthHandler()
+}
+
+// Check a service using the default processor.
+func Check(s interface{}) error {
+	return DefaultProcessor.Check(s)
+}
+
 // A Hook can be used to act upon every field visited by the repository when
 // configuring a service.
 type Hook func(ctx context.Context, field *Field) error
@@ -39,6 +77,13 @@ type Provider interface {
 	Priority() int
 }
 
+// Watcher is an optional interface implemented by the providers able to
+// signal changes of their values. The returned channel receives a value on
+// every change, until the context is done.
+type Watcher interface {
+	Watch(ctx context.Context) <-chan struct{}
+}
+
 // Add a provider to the default repository.
 func AddProviders(providers ...Provider) {
 	DefaultRepository.AddProviders(providers...)
@@ -52,3 +97,9 @@ type Parser func(interface{}, interface{}) error
 func AddParsers(parsers ...Parser) {
 	DefaultRepository.AddParsers(parsers...)
 }
+
+// Logger is the interface used to report the events that aren't errors, like
+// the retries of a field initialization. It is implemented by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}